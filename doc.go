@@ -9,6 +9,8 @@ zero dependencies on packages outside the standard library.
 
 Usage:
 	esc [flag] [name ...]
+	esc append [flag] executable [name ...]
+	esc unused [flag] generated.go ...
 
 The flags are:
 	-o=""
@@ -16,7 +18,16 @@ The flags are:
 	-pkg="main"
 		package name of output file, defaults to main
 	-prefix=""
-		strip given prefix from filenames
+		strip given directory prefix from filenames
+	-prefix-for=""
+		strip a different prefix from the filenames below one of the names,
+		given as "name=prefix", e.g. -prefix-for frontend/build=frontend/build;
+		the argument "name::prefix" does the same; may be repeated
+	-mount=""
+		embed a file or directory under another name, given as "source:/name",
+		e.g. -mount web/dist:/static -mount LICENSE:/about/license.txt;
+		directories mounted at the same name are merged, while files embedded
+		twice are an error; may be repeated
 	-ignore=""
 		regular expression for files to ignore
 	-include=""
@@ -25,8 +36,80 @@ The flags are:
 		Unix timestamp to override as modification time for all files
 	-private
 		unexport functions by prefixing them with esc, e.g. FS -> escFS
+	-bundle=""
+		add a name to all generated identifiers and environment variables so
+		several outputs can share a package, e.g. FS -> AdminFS for -bundle=Admin,
+		or _escAdmin_FS with -private
+	-handler
+		generate a Handler function returning a http.Handler that serves the
+		files, with directory listings rendered from a template or disabled
+	-local-fallback
+		in local mode, also serve files added on disk below an embedded
+		directory after generation, even those -ignore, -include or -exec
+		would exclude
+	-asset-consts
+		generate a constant holding the name of each file, e.g.
+		AssetCSSMainCSS = "/css/main.css", so that typos fail to compile
+	-exec=""
+		pipe files through a program before embedding, given as "pattern
+		[-> replacement] program [arg ...]": files whose path matches the
+		pattern are written to the standard input of the program and replaced
+		by its standard output; the replacement, if any, substitutes the matches
+		of the pattern in the file name, e.g. -exec "\.scss$ -> .css sassc -s";
+		may be repeated
+	-cache=""
+		directory to cache the output of -exec programs in, keyed by a hash of
+		the program, the file name and its contents
+	-minify=""
+		regular expression for CSS, HTML, JSON and SVG files to minify before
+		compression, e.g. \.(css|html)$; other files, including JavaScript,
+		are left as they are, so minify those with -exec
 	-no-compress
 		do not compress files
+	-force-compress
+		compress files that already are compressed, such as gzip, zip, PNG,
+		JPEG and WOFF2 files, which are detected by their contents and
+		otherwise stored as they are; esc prints how many files and bytes were
+		stored that way and about how much time that saved
+	-precompressed
+		embed files ending in .br or .gz next to another file, such as
+		app.js.br next to app.js, as encodings of that file rather than as
+		files of their own; the -handler serves them to clients accepting
+		the encoding
+	-archive=""
+		write the compressed files to this archive instead of the output file;
+		the generated code reads it from the end of the executable, else from
+		this path (see SetArchivePath)
+	-shard-size=0
+		split the output into several files in the same package (named like
+		static_1.go next to the -o file) holding about this many bytes of
+		compressed data each
+	-reproducible
+		produce identical output on every machine; modification times come
+		from -modtime or SOURCE_DATE_EPOCH, else zero
+	-max-file-size=0
+		fail if a file is larger than this many bytes
+	-max-total-size=0
+		fail if the files total more than this many bytes
+	-max-compressed-size=0
+		fail if the files total more than this many bytes after compression,
+		counted as embedded: in base64, about a third larger, unless -archive
+		is set; the error lists the largest files counting against the
+		exceeded limit
+	-report
+		print the number of files and their size before and after compression
+		below each directory to standard error
+	-stats
+		print the size before and after compression, the compression ratio and
+		the time spent compressing of every file and directory to standard
+		error, as a table, or as JSON with -stats=json; the format must follow
+		an "=", since in "-stats json" json is taken as a file to embed
+	-depfile=""
+		write a dependency file in Makefile syntax to this path, making the
+		output files depend on every file and directory read; directories are
+		listed as adding or removing files in them changes the output
+	-depfile-json
+		write the -depfile as JSON, with "outputs", "files" and "dirs" arrays
 
 Accessing Embedded Files
 
@@ -37,6 +120,71 @@ development).
 FS(Must)?(Byte|String) returns an asset as a (byte slice|string).
 FSMust(Byte|String) panics if the asset is not found.
 
+In local mode, paths are resolved against the directory esc was run from.
+When the program runs elsewhere, set the root with SetLocalRoot or the
+ESC_LOCAL_ROOT environment variable. With -local-fallback, files added on
+disk under an embedded directory after generation are served in local mode
+as well. The fallback serves every file below those directories, including
+ignored ones and secrets such as .env files, so only enable it for
+development.
+
+Names are cleaned before they are looked up, so "//css/./main.css" opens
+"/css/main.css". Names leading out of the root, such as "../secret", are
+not found, in static and local mode alike; for Dir(useLocal, "/static"),
+the root is /static.
+
+With -handler, Handler(fs, opts) returns a http.Handler serving the files of
+FS or Dir like http.FileServer. Directory listings are rendered from
+opts.Listing, an html/template executed with a Listing holding the path and
+the entries sorted by name, or answered with 404 Not Found if
+opts.NoListing is set. A directory holding an index.html is answered with
+it instead; opts.Index sets other names to try, in order, and opts.NoIndex
+always lists. Index files are found the same way for FS and Dir, in static
+and local mode:
+
+	http.Handle("/", Handler(FS(false), &HandlerOptions{NoListing: true}))
+
+Range requests, including multiple ranges and If-Range, are answered with
+the requested bytes. An embedded file is only inflated as far as the ranges
+read reach, so seeking near the start of a large video or PDF does not
+inflate all of it; once a file has been read to the end, it is kept
+inflated.
+
+With -precompressed as well, the handler answers clients that accept br or
+gzip with the app.js.br or app.js.gz embedded for app.js, preferring br, and
+sets Content-Encoding and Vary. In local mode these files are read from disk
+next to the file. esc does not compress to br itself; create the .br files
+with another tool before running it. A file with encodings cannot be changed
+by -minify or -exec, as its encodings would no longer match it; esc fails
+instead.
+
+Appending Assets to an Executable
+
+Code generated with -archive reads its assets from an archive appended to
+the executable, if there is one. This allows a program to be built once and
+shipped with different assets:
+
+	esc -archive static.esc -o static.go
+	go build -o app
+	esc append app static
+
+The bundle, prefix, prefix-for, mount, ignore, include, modtime, exec, cache,
+minify, no-compress, force-compress, precompressed, reproducible, max-file-size,
+max-total-size, max-compressed-size, report, stats, depfile and depfile-json
+flags and the name::prefix syntax apply to esc append as well. Running it again replaces the appended archive.
+Appending to a code-signed executable invalidates its signature.
+
+Unused Assets
+
+esc unused lists the files embedded by the given generated files that are
+never referenced by the Go and template (.html, .htm, .tmpl, .tpl, .gohtml)
+files under the -root directory, which defaults to the current directory. A
+file counts as referenced if a string literal or template contains its name,
+such as "css/main.css" in "/static/css/main.css?v=2", or if its -asset-consts
+constant is used. esc exits with status 1 if any file is unused:
+
+	esc unused [-root dir] static.go
+
 Go Generate
 
 esc can be invoked by go generate:
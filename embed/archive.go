@@ -0,0 +1,278 @@
+package embed
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// archiveMagic ends every archive written by esc.
+	archiveMagic = "escarch1"
+	// trailerLen is the length of the trailer ending an archive.
+	trailerLen = 24
+)
+
+// Append writes an archive of conf.Files and conf.Mounts to the end of the
+// executable exe, replacing any archive appended to it earlier for the same
+// Config.Bundle and keeping those of other bundles. Code generated with
+// Config.Archive set finds the archive of its bundle there at run time, so
+// the same build can be shipped with different assets. Only the options that
+// select and compress files are used from conf. The executable is replaced
+// only once the new one is written in full.
+func Append(conf *Config, exe string) error {
+	g, err := newGenerator(conf)
+	if err != nil {
+		return err
+	}
+	if err := g.collect(); err != nil {
+		return err
+	}
+	files, dirs := g.sorted()
+	if err := appendArchive(exe, conf.Bundle, files, dirs); err != nil {
+		return &FileError{Path: exe, Err: err}
+	}
+	if err := g.writeDepFile([]string{exe}); err != nil {
+		return err
+	}
+	g.logSummary()
+	return nil
+}
+
+// appendArchive writes a copy of the file name with the archive for bundle
+// in place of the one appended earlier, if any, and renames it to name.
+func appendArchive(name, bundle string, files []*_escFile, dirs []*_escDir) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	archives, err := findArchives(f)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".esc")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = func() error {
+		w := bufio.NewWriter(tmp)
+		// Copy the executable and the archives of other bundles.
+		end := fi.Size()
+		if len(archives) > 0 {
+			end = archives[0].start
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(f, 0, end)); err != nil {
+			return err
+		}
+		for _, a := range archives {
+			if a.index.Bundle == bundle {
+				continue
+			}
+			if _, err := io.Copy(w, io.NewSectionReader(f, a.start, a.end-a.start)); err != nil {
+				return err
+			}
+		}
+		if err := writeArchive(w, bundle, files, dirs); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return tmp.Chmod(fi.Mode())
+	}()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Rename(tmp.Name(), name)
+}
+
+// archiveSpan is an archive found in a file, from start to end.
+type archiveSpan struct {
+	start, end int64
+	index      archiveIndex
+}
+
+// findArchives returns the archives at the end of f, which follow each other,
+// in the order they were appended.
+func findArchives(f *os.File) ([]archiveSpan, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var archives []archiveSpan
+	for end := fi.Size(); ; {
+		a, ok, err := archiveBefore(f, end)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		archives = append([]archiveSpan{a}, archives...)
+		end = a.start
+	}
+	return archives, nil
+}
+
+// archiveBefore returns the archive ending at offset end of r. ok is false
+// if there is none.
+func archiveBefore(r io.ReaderAt, end int64) (a archiveSpan, ok bool, err error) {
+	if end < trailerLen {
+		return a, false, nil
+	}
+	var trailer [trailerLen]byte
+	if _, err := r.ReadAt(trailer[:], end-trailerLen); err != nil {
+		return a, false, err
+	}
+	if string(trailer[16:]) != archiveMagic {
+		return a, false, nil
+	}
+	indexOff := int64(binary.LittleEndian.Uint64(trailer[0:8]))
+	indexLen := int64(binary.LittleEndian.Uint64(trailer[8:16]))
+	start := end - trailerLen - indexLen - indexOff
+	if indexOff < 0 || indexLen < 0 || start < 0 {
+		return a, false, nil
+	}
+	b := make([]byte, indexLen)
+	if _, err := r.ReadAt(b, start+indexOff); err != nil {
+		return a, false, err
+	}
+	a = archiveSpan{start: start, end: end}
+	if err := json.Unmarshal(b, &a.index); err != nil {
+		return a, false, err
+	}
+	return a, true, nil
+}
+
+// readArchiveIndex reads the index of the archive at the end of the file
+// name.
+func readArchiveIndex(name string) (archiveIndex, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return archiveIndex{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return archiveIndex{}, err
+	}
+	a, ok, err := archiveBefore(f, fi.Size())
+	if err == nil && !ok {
+		err = errors.New("no archive found")
+	}
+	return a.index, err
+}
+
+// archiveIndex is the index of an archive. Bundle is the Config.Bundle the
+// archive was written for.
+type archiveIndex struct {
+	Bundle  string         `json:"bundle"`
+	Entries []archiveEntry `json:"entries"`
+}
+
+// archiveEntry describes a file or directory in the index of an archive.
+type archiveEntry struct {
+	Name     string   `json:"name"`
+	Local    string   `json:"local"`
+	Size     int64    `json:"size,omitempty"`
+	ModTime  int64    `json:"modtime,omitempty"`
+	Offset   int64    `json:"offset,omitempty"`
+	Length   int64    `json:"length,omitempty"`
+	IsDir    bool     `json:"dir,omitempty"`
+	Children []string `json:"children,omitempty"`
+	// Encodings maps the precompressed encodings of a file to their offset
+	// and length.
+	Encodings map[string][2]int64 `json:"encodings,omitempty"`
+}
+
+// writeArchive writes the gzipped data of files, followed by a JSON index of
+// files and dirs and a trailer holding the offset and length of the index
+// and archiveMagic. Offsets are relative to the start of the archive and
+// the trailer is at its end, so an archive may be appended to any file,
+// such as an executable, and still be found.
+func writeArchive(w io.Writer, bundle string, files []*_escFile, dirs []*_escDir) error {
+	var off int64
+	entries := make([]archiveEntry, 0, len(files)+len(dirs))
+	for _, f := range files {
+		if _, err := w.Write(f.gzipped); err != nil {
+			return err
+		}
+		e := archiveEntry{
+			Name:    f.Name,
+			Local:   f.Local,
+			Size:    int64(len(f.Data)),
+			ModTime: f.ModTime,
+			Offset:  off,
+			Length:  int64(len(f.gzipped)),
+		}
+		off += int64(len(f.gzipped))
+		for _, enc := range f.Encodings {
+			if _, err := w.Write(enc.Data); err != nil {
+				return err
+			}
+			if e.Encodings == nil {
+				e.Encodings = make(map[string][2]int64)
+			}
+			e.Encodings[enc.Name] = [2]int64{off, int64(len(enc.Data))}
+			off += int64(len(enc.Data))
+		}
+		entries = append(entries, e)
+	}
+	for _, d := range dirs {
+		entries = append(entries, archiveEntry{
+			Name:     d.Name,
+			Local:    d.Local,
+			IsDir:    true,
+			Children: d.ChildFileNames,
+		})
+	}
+	index, err := json.Marshal(archiveIndex{Bundle: bundle, Entries: entries})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(index); err != nil {
+		return err
+	}
+	var trailer [trailerLen]byte
+	binary.LittleEndian.PutUint64(trailer[0:8], uint64(off))
+	binary.LittleEndian.PutUint64(trailer[8:16], uint64(len(index)))
+	copy(trailer[16:], archiveMagic)
+	_, err = w.Write(trailer[:])
+	return err
+}
+
+// writeArchive writes the archive of the collected files to conf.Archive.
+func (g *generator) writeArchive() error {
+	name := g.conf.Archive
+	f, err := os.Create(name)
+	if err != nil {
+		return &FileError{Path: name, Err: err}
+	}
+	w := bufio.NewWriter(f)
+	err = writeArchive(w, g.conf.Bundle, g.files, g.dirs)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return &FileError{Path: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package embed
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// compressedSize returns the bytes f takes up in the output: its compressed
+// data and its precompressed encodings.
+func (f *_escFile) compressedSize() int64 {
+	n := int64(len(f.gzipped))
+	for _, e := range f.Encodings {
+		n += int64(len(e.Data))
+	}
+	return n
+}
+
+// embeddedSize returns the bytes f takes up in the generated code or, with
+// Config.Archive, in the archive. Generated code holds the compressed data
+// in base64, which is about a third larger.
+func (g *generator) embeddedSize(f *_escFile) int64 {
+	if g.conf.Archive != "" {
+		return f.compressedSize()
+	}
+	n := int64(len(f.Compressed))
+	for _, e := range f.Encodings {
+		n += int64(len(e.Encoded))
+	}
+	return n
+}
+
+// checkSizes writes the size report to Config.Report, if set, and returns a
+// *SizeError if the collected files exceed a size limit.
+func (g *generator) checkSizes() error {
+	if g.conf.Report != nil {
+		if err := g.report(); err != nil {
+			return err
+		}
+	}
+	if max := g.conf.MaxFileSize; max > 0 {
+		var over []FileSize
+		for _, f := range g.files {
+			if n := int64(len(f.Data)); n > max {
+				over = append(over, FileSize{Name: f.Name, Size: n})
+			}
+		}
+		if len(over) > 0 {
+			rankSizes(over)
+			return &SizeError{Limit: "MaxFileSize", Max: max, Size: over[0].Size, Files: over}
+		}
+	}
+	for _, limit := range []struct {
+		name string
+		max  int64
+		size func(*_escFile) int64
+	}{
+		{"MaxTotalSize", g.conf.MaxTotalSize, func(f *_escFile) int64 { return int64(len(f.Data)) }},
+		{"MaxCompressedSize", g.conf.MaxCompressedSize, g.embeddedSize},
+	} {
+		if limit.max <= 0 {
+			continue
+		}
+		var total int64
+		sizes := make([]FileSize, 0, len(g.files))
+		for _, f := range g.files {
+			n := limit.size(f)
+			total += n
+			sizes = append(sizes, FileSize{Name: f.Name, Size: n})
+		}
+		if total > limit.max {
+			rankSizes(sizes)
+			return &SizeError{Limit: limit.name, Max: limit.max, Size: total, Files: sizes}
+		}
+	}
+	return nil
+}
+
+// rankSizes sorts sizes largest first, then by name.
+func rankSizes(sizes []FileSize) {
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Size != sizes[j].Size {
+			return sizes[i].Size > sizes[j].Size
+		}
+		return sizes[i].Name < sizes[j].Name
+	})
+}
+
+// dirSize is the size of the files below an embedded directory.
+type dirSize struct {
+	files        int
+	size         int64
+	compressed   int64
+	compressTime time.Duration
+}
+
+// dirSizes returns the sizes of the files below each directory, including
+// the directories only leading to files.
+func (g *generator) dirSizes() map[string]*dirSize {
+	sizes := make(map[string]*dirSize)
+	for _, f := range g.files {
+		for dir := path.Dir(f.Name); ; dir = path.Dir(dir) {
+			s, ok := sizes[dir]
+			if !ok {
+				s = &dirSize{}
+				sizes[dir] = s
+			}
+			s.files++
+			s.size += int64(len(f.Data))
+			s.compressed += f.compressedSize()
+			s.compressTime += f.compressTime
+			if dir == "/" {
+				break
+			}
+		}
+	}
+	return sizes
+}
+
+// report writes the number of files and their size before and after
+// compression for each directory to Config.Report.
+func (g *generator) report() error {
+	sizes := g.dirSizes()
+	dirs := make([]string, 0, len(sizes))
+	for dir := range sizes {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	w := tabwriter.NewWriter(g.conf.Report, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "DIRECTORY\tFILES\tSIZE\tCOMPRESSED\n")
+	for _, dir := range dirs {
+		s := sizes[dir]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", dir, s.files, s.size, s.compressed)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,76 @@
+package embed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Command pipes files through an external program between reading and
+// compression.
+type Command struct {
+	// Pattern is the regexp for the files to pass to the program. Like
+	// Include, it is matched against the file path.
+	Pattern string
+	// Rename, if set, replaces the matches of Pattern in the embedded name,
+	// as by regexp.ReplaceAllString; for example ".css" for `\.scss$`.
+	Rename string
+	// Args are the program and its arguments. The program reads the file on
+	// its standard input and writes the result to its standard output. The
+	// ESC_FILE and ESC_NAME environment variables hold the path and the
+	// embedded name of the file.
+	Args []string
+}
+
+// command returns the transform running c.
+func (g *generator) command(c Command, re *regexp.Regexp) func(string, string, []byte) (string, []byte, error) {
+	return func(fname, name string, data []byte) (string, []byte, error) {
+		if c.Rename != "" {
+			name = re.ReplaceAllString(name, c.Rename)
+		}
+		var key string
+		if g.conf.CacheDir != "" {
+			h := sha256.New()
+			for _, s := range c.Args {
+				h.Write([]byte(s))
+				h.Write([]byte{0})
+			}
+			h.Write([]byte(name))
+			h.Write([]byte{0})
+			h.Write(data)
+			key = filepath.Join(g.conf.CacheDir, hex.EncodeToString(h.Sum(nil)))
+			if b, err := ioutil.ReadFile(key); err == nil {
+				return name, b, nil
+			}
+		}
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(c.Args[0], c.Args[1:]...)
+		cmd.Env = append(os.Environ(), "ESC_FILE="+fname, "ESC_NAME="+name)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", nil, &CommandError{Args: c.Args, Stderr: strings.TrimSpace(stderr.String()), Err: err}
+		}
+
+		if key != "" {
+			// Write the result under a temporary name first so that an
+			// interrupted run leaves no partial entry.
+			tmp := key + ".tmp"
+			if err := ioutil.WriteFile(tmp, stdout.Bytes(), 0644); err != nil {
+				return "", nil, err
+			}
+			if err := os.Rename(tmp, key); err != nil {
+				return "", nil, err
+			}
+		}
+		return name, stdout.Bytes(), nil
+	}
+}
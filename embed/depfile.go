@@ -0,0 +1,113 @@
+package embed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// input records that the file or directory fname was read, for DepFile. The
+// listings of directories count, so that adding or removing a file reruns
+// esc.
+func (g *generator) input(fname string, dir bool) {
+	if g.conf.DepFile == "" || g.seen[fname] {
+		return
+	}
+	if g.seen == nil {
+		g.seen = make(map[string]bool)
+	}
+	g.seen[fname] = true
+	if dir {
+		g.inputDirs = append(g.inputDirs, fname)
+	} else {
+		g.inputFiles = append(g.inputFiles, fname)
+	}
+}
+
+// depFile is the JSON form of a dependency file.
+type depFile struct {
+	// Outputs are the files written.
+	Outputs []string `json:"outputs"`
+	// Files and Dirs are the files and directories read.
+	Files []string `json:"files"`
+	Dirs  []string `json:"dirs"`
+}
+
+// writeDepFile writes Config.DepFile, listing the inputs read for outputs.
+func (g *generator) writeDepFile(outputs []string) error {
+	name := g.conf.DepFile
+	if name == "" {
+		return nil
+	}
+	if len(outputs) == 0 {
+		return &ConfigError{Option: "DepFile", Err: errors.New("a dependency file requires an output file")}
+	}
+	// Sort the inputs, as the order directories are read in depends on
+	// the file system.
+	sort.Strings(g.inputFiles)
+	sort.Strings(g.inputDirs)
+	var data []byte
+	if g.conf.DepFileJSON {
+		var err error
+		data, err = json.MarshalIndent(depFile{
+			Outputs: slashPaths(outputs),
+			Files:   slashPaths(g.inputFiles),
+			Dirs:    slashPaths(g.inputDirs),
+		}, "", "\t")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = makeDeps(outputs, append(append([]string(nil), g.inputFiles...), g.inputDirs...), g.inputFiles)
+	}
+	if err := ioutil.WriteFile(name, data, 0644); err != nil {
+		return &FileError{Path: name, Err: err}
+	}
+	return nil
+}
+
+// makeDeps returns a Makefile rule making targets depend on deps, followed
+// by an empty rule for each of phony, so that make does not fail once one of
+// them is removed.
+func makeDeps(targets, deps, phony []string) []byte {
+	var b bytes.Buffer
+	for i, t := range targets {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(makeEscape(t))
+	}
+	b.WriteByte(':')
+	for _, d := range deps {
+		b.WriteString(" \\\n  ")
+		b.WriteString(makeEscape(d))
+	}
+	b.WriteByte('\n')
+	for _, p := range phony {
+		fmt.Fprintf(&b, "\n%s:\n", makeEscape(p))
+	}
+	return b.Bytes()
+}
+
+// makeEscaper escapes the characters that are special in Makefile rules.
+var makeEscaper = strings.NewReplacer(" ", `\ `, "#", `\#`, "$", "$$")
+
+// makeEscape returns the path name, in slash form, escaped for a Makefile.
+func makeEscape(name string) string {
+	return makeEscaper.Replace(filepath.ToSlash(name))
+}
+
+// slashPaths returns names in slash form.
+func slashPaths(names []string) []string {
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.ToSlash(name)
+	}
+	return paths
+}
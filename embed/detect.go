@@ -0,0 +1,63 @@
+package embed
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// compressedTypes are the content types, as sniffed by
+// http.DetectContentType, of data that gzip cannot shrink noticeably.
+var compressedTypes = map[string]bool{
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/x-rar-compressed": true,
+	"application/ogg":              true,
+	"audio/mpeg":                   true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+	"image/gif":                    true,
+	"image/jpeg":                   true,
+	"image/png":                    true,
+	"image/webp":                   true,
+	"video/mp4":                    true,
+	"video/webm":                   true,
+}
+
+// isCompressed reports whether data is in a compressed format, judged by
+// its first bytes.
+func isCompressed(data []byte) bool {
+	return compressedTypes[http.DetectContentType(data)]
+}
+
+// compressStats records the time spent compressing, and the files stored
+// without compression because they already were compressed.
+type compressStats struct {
+	// compressed and compressTime are the bytes compressed and the time it
+	// took.
+	compressed   int64
+	compressTime time.Duration
+	// stored and storedFiles are the bytes and the number of files stored
+	// without compression.
+	stored      int64
+	storedFiles int
+}
+
+// logSummary writes a summary of the files stored without compression to
+// Config.Log, if any were.
+func (g *generator) logSummary() {
+	s := g.stats
+	if g.conf.Log == nil || s.storedFiles == 0 {
+		return
+	}
+	msg := fmt.Sprintf("esc: stored %d already compressed files (%d bytes) as they are", s.storedFiles, s.stored)
+	if s.compressed > 0 {
+		// Estimate the time saved from the rate the other files were
+		// compressed at.
+		saved := time.Duration(float64(s.compressTime) * float64(s.stored) / float64(s.compressed))
+		if saved >= time.Millisecond {
+			msg += fmt.Sprintf(", saving about %v of compression", saved.Round(time.Millisecond))
+		}
+	}
+	fmt.Fprintln(g.conf.Log, msg)
+}
@@ -4,8 +4,9 @@ package embed
 import (
 	"bytes"
 	"compress/gzip"
-	"encoding/base64"
+	"errors"
 	"fmt"
+	"go/format"
 	"io"
 	"io/ioutil"
 	"os"
@@ -16,9 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
-
-	"github.com/pkg/errors"
-	"golang.org/x/tools/imports"
+	"time"
 )
 
 // Config contains all information needed to run esc.
@@ -27,8 +26,12 @@ type Config struct {
 	OutputFile string
 	// Package name for the generated file.
 	Package string
-	// Prefix is stripped from filenames.
+	// Prefix is stripped from filenames. It only matches whole path
+	// elements, with or without a trailing slash.
 	Prefix string
+	// Prefixes maps entries of Files to the prefix stripped from the names
+	// below them instead of Prefix.
+	Prefixes map[string]string
 	// Ignore is the regexp for files we should ignore (for example `\.DS_Store`).
 	Ignore string
 	// Include is the regexp for files to include. If provided, only files that
@@ -38,25 +41,124 @@ type Config struct {
 	ModTime string
 	// Private, if true, causes autogenerated functions to be unexported.
 	Private bool
+	// Bundle, if set, is added to all generated identifiers and environment
+	// variables, so that several outputs can share a package. For example,
+	// with Bundle "Admin" the functions are AdminFS, AdminDir and so on, and
+	// the local root is read from ESC_ADMIN_LOCAL_ROOT. Unexported
+	// identifiers have the bundle and an underscore after their _esc prefix,
+	// as in _escAdmin_FS with Private.
+	Bundle string
+	// Handler, if true, generates a Handler function returning a http.Handler
+	// that serves the files like http.FileServer, with configurable directory
+	// listings.
+	Handler bool
+	// LocalFallback, if true, makes the local filesystem also serve files
+	// added below an embedded directory after generation. It serves any file
+	// there as it is on disk, including files excluded by Ignore, Include or
+	// Transforms.
+	LocalFallback bool
+	// AssetConsts, if true, generates a string constant holding the name of
+	// each embedded file, so that references are checked by the compiler.
+	// "/css/main.css" becomes AssetCSSMainCSS.
+	AssetConsts bool
+	// Transforms rewrite, rename or drop files before compression, in order.
+	// Local mode serves files as they are on disk.
+	Transforms []Transform
+	// Commands pipe files through external programs before compression,
+	// in order, after Transforms.
+	Commands []Command
+	// CacheDir, if set, is the directory where the output of Commands is
+	// cached, keyed by a hash of the program, the file name and its
+	// contents.
+	CacheDir string
+	// Minify is the regexp for files to minify before compression, after
+	// Commands. CSS, HTML, JSON and SVG files are minified, by extension;
+	// other matching files are left as they are. That includes JavaScript,
+	// which cannot be minified safely without a full parser; pipe it through
+	// a minifier with Commands instead.
+	Minify string
 	// NoCompression, if true, stores the files without compression.
 	NoCompression bool
+	// ForceCompression, if true, compresses files that are already
+	// compressed, such as PNG images or WOFF2 fonts. They are detected by
+	// their contents and otherwise stored as they are.
+	ForceCompression bool
+	// Precompressed, if true, embeds files ending in .br or .gz next to
+	// another embedded file as encodings of that file rather than as files
+	// of their own. The generated handler serves them to clients that accept
+	// the encoding, preferring br. A file with encodings must not be changed
+	// by a transform, as the encodings would no longer match it.
+	Precompressed bool
+	// MaxFileSize, if positive, is the largest size in bytes a file may have
+	// before compression.
+	MaxFileSize int64
+	// MaxTotalSize and MaxCompressedSize, if positive, limit the total size
+	// in bytes of the files before and after compression. MaxCompressedSize
+	// counts the bytes embedded: the base64 text in the generated code,
+	// which is about a third larger than the compressed data, or the
+	// compressed data in the Archive. Precompressed encodings count towards
+	// it.
+	MaxTotalSize      int64
+	MaxCompressedSize int64
+	// Report, if set, receives the number of files and their size before and
+	// after compression below each directory.
+	Report io.Writer
+	// Stats, if set, receives the size before and after compression, the
+	// compression ratio and the time spent compressing of every embedded
+	// file and directory, as a table or, if StatsJSON is set, as JSON.
+	Stats     io.Writer
+	StatsJSON bool
+	// DepFile, if set, is the dependency file to write, listing the files
+	// and directories read for the output in Makefile syntax or, if
+	// DepFileJSON is set, as JSON. Directories are listed because adding or
+	// removing a file in them changes the output.
+	DepFile     string
+	DepFileJSON bool
+	// Log, if set, receives a summary of the files stored without
+	// compression because they already were compressed.
+	Log io.Writer
 	// Invocation, if set, is added to the invocation string in the generated template.
 	Invocation string
+	// Archive, if set, is the file the compressed assets are written to,
+	// instead of the generated source. The generated code reads them from an
+	// archive appended to the executable, else from this path.
+	Archive string
+	// ShardSize, if positive, splits the output across several files in the
+	// same package once the compressed assets exceed this many bytes. The
+	// extra files are written next to OutputFile, which must be set.
+	ShardSize int64
+	// Reproducible, if true, produces output that does not depend on the
+	// machine it was generated on. Modification times are taken from ModTime,
+	// else the SOURCE_DATE_EPOCH environment variable, else zero. Absolute
+	// paths are made relative to the working directory.
+	Reproducible bool
 
 	// Files is the list of files or directories to embed.
 	Files []string
+	// Mounts embed files or directories at chosen names, in addition to
+	// Files. Directories mounted at the same name are merged. Directories
+	// that only lead to a mount have no local path, so local mode cannot
+	// open them.
+	Mounts []Mount
 }
 
-var modTime *int64
-
-var tmpl = template.Must(template.New("").Parse(fileTemplate))
+var tmpl = template.Must(template.New("file").Parse(fileTemplate + shardTemplate))
 
 type templateParams struct {
 	Invocation     string
 	PackageName    string
 	FunctionPrefix string
+	Bundle         string
+	Ident          string
+	EnvPrefix      string
+	Archive        string
+	Handler        bool
+	LocalFallback  bool
+	Precompressed  bool
+	Assets         []assetConst
 	Files          []*_escFile
 	Dirs           []*_escDir
+	ShardOf        string
 }
 
 type _escFile struct {
@@ -66,8 +168,23 @@ type _escFile struct {
 	Local      string
 	ModTime    int64
 	Compressed string
+	Encodings  []*_escEncoding
 
 	fileinfo os.FileInfo
+	gzipped  []byte
+	// transformed is set if a transform changed the name or contents of the
+	// file.
+	transformed bool
+	// stored is set if the file is stored without compression because it
+	// already is compressed. compressTime is the time compressing took.
+	stored       bool
+	compressTime time.Duration
+}
+
+// assetConst is a constant generated for an embedded file.
+type assetConst struct {
+	Ident string
+	Name  string
 }
 
 type _escDir struct {
@@ -77,144 +194,629 @@ type _escDir struct {
 	ChildFileNames []string
 }
 
-// Run executes a Config.
+// Run executes a Config. It is safe to call Run concurrently with different
+// Configs. Failures are reported as a *ConfigError, *DuplicateNameError,
+// *FileError, *PatternError, *SizeError, *TemplateError or *FormatError where
+// they apply. A failing
+// program from Commands is reported as a *CommandError in a *FileError.
 func Run(conf *Config, out io.Writer) error {
+	g, err := newGenerator(conf)
+	if err != nil {
+		return err
+	}
+	if err := g.collect(); err != nil {
+		return err
+	}
+	if err := g.write(out); err != nil {
+		return err
+	}
+	if err := g.writeDepFile(g.outputs); err != nil {
+		return err
+	}
+	g.logSummary()
+	return nil
+}
+
+// generator holds the state of a single Run.
+type generator struct {
+	conf      *Config
+	modTime   *int64
+	prefix    string
+	ignore    *regexp.Regexp
+	include   *regexp.Regexp
+	gzipLevel int
+	// transforms are applied in order to the contents of each file.
+	transforms []transform
+	// renamed maps the names of the files renamed by transforms to their
+	// new names, which are empty for dropped files.
+	renamed map[string]string
+
+	files    []*_escFile
+	dirs     []*_escDir
+	prepared map[string]string
+	// stripped maps the names of the collected files and directories to the
+	// prefix stripped from their paths.
+	stripped map[string]string
+	// dirIndex maps the names of the collected directories to them.
+	dirIndex map[string]*_escDir
+	stats    compressStats
+	// inputFiles and inputDirs are the files and directories read, for
+	// DepFile.
+	inputFiles []string
+	inputDirs  []string
+	seen       map[string]bool
+	// outputs are the files written by write.
+	outputs []string
+}
+
+var bundleRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func newGenerator(conf *Config) (*generator, error) {
+	if conf.Bundle != "" && !bundleRegexp.MatchString(conf.Bundle) {
+		return nil, &ConfigError{Option: "Bundle", Err: fmt.Errorf("%q is not a valid identifier", conf.Bundle)}
+	}
+	g := &generator{
+		conf:      conf,
+		prefix:    filepath.ToSlash(conf.Prefix),
+		gzipLevel: gzip.BestCompression,
+		prepared:  make(map[string]string, 10),
+		dirIndex:  make(map[string]*_escDir),
+		stripped:  make(map[string]string),
+	}
 	var err error
 	if conf.ModTime != "" {
 		i, err := strconv.ParseInt(conf.ModTime, 10, 64)
 		if err != nil {
-			return fmt.Errorf("modtime must be an integer: %v", err)
+			return nil, &ConfigError{Option: "ModTime", Err: err}
+		}
+		g.modTime = &i
+	} else if conf.Reproducible {
+		var i int64
+		if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+			i, err = strconv.ParseInt(epoch, 10, 64)
+			if err != nil {
+				return nil, &ConfigError{Option: "SOURCE_DATE_EPOCH", Err: err}
+			}
 		}
-		modTime = &i
+		g.modTime = &i
 	}
-
-	alreadyPrepared := make(map[string]bool, 10)
-	escFiles := make([]*_escFile, 0, 10)
-	prefix := filepath.ToSlash(conf.Prefix)
-	var ignoreRegexp *regexp.Regexp
 	if conf.Ignore != "" {
-		ignoreRegexp, err = regexp.Compile(conf.Ignore)
+		g.ignore, err = regexp.Compile(conf.Ignore)
 		if err != nil {
-			return err
+			return nil, &PatternError{Option: "Ignore", Pattern: conf.Ignore, Err: err}
 		}
 	}
-	var includeRegexp *regexp.Regexp
 	if conf.Include != "" {
-		includeRegexp, err = regexp.Compile(conf.Include)
+		g.include, err = regexp.Compile(conf.Include)
 		if err != nil {
-			return err
+			return nil, &PatternError{Option: "Include", Pattern: conf.Include, Err: err}
 		}
 	}
-	gzipLevel := gzip.BestCompression
+	for _, t := range conf.Transforms {
+		re, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			return nil, &PatternError{Option: "Transforms", Pattern: t.Pattern, Err: err}
+		}
+		fn := t.Func
+		g.transforms = append(g.transforms, transform{pattern: re, apply: func(fname, name string, data []byte) (string, []byte, error) {
+			return fn(name, data)
+		}})
+	}
+	for _, c := range conf.Commands {
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, &PatternError{Option: "Commands", Pattern: c.Pattern, Err: err}
+		}
+		if len(c.Args) == 0 {
+			return nil, &ConfigError{Option: "Commands", Err: fmt.Errorf("command for %q has no program", c.Pattern)}
+		}
+		g.transforms = append(g.transforms, transform{pattern: re, apply: g.command(c, re)})
+	}
+	if conf.CacheDir != "" {
+		if err := os.MkdirAll(conf.CacheDir, 0755); err != nil {
+			return nil, &FileError{Path: conf.CacheDir, Err: err}
+		}
+	}
+	if conf.Minify != "" {
+		re, err := regexp.Compile(conf.Minify)
+		if err != nil {
+			return nil, &PatternError{Option: "Minify", Pattern: conf.Minify, Err: err}
+		}
+		g.transforms = append(g.transforms, transform{pattern: re, apply: func(fname, name string, data []byte) (string, []byte, error) {
+			data, err := minify(name, data)
+			return name, data, err
+		}})
+	}
 	if conf.NoCompression {
-		gzipLevel = gzip.NoCompression
-	}
-	directories := make([]*_escDir, 0, 10)
-	for _, base := range conf.Files {
-		files := []string{base}
-		for len(files) > 0 {
-			fname := files[0]
-			files = files[1:]
-			if ignoreRegexp != nil && ignoreRegexp.MatchString(fname) {
+		g.gzipLevel = gzip.NoCompression
+	}
+	return g, nil
+}
+
+func (g *generator) ignored(fname string) bool {
+	return g.ignore != nil && g.ignore.MatchString(fname)
+}
+
+func (g *generator) included(fname string) bool {
+	return g.include == nil || g.include.MatchString(fname)
+}
+
+// collect adds Files and Mounts.
+func (g *generator) collect() error {
+	for _, base := range g.conf.Files {
+		if err := g.walk(base); err != nil {
+			return err
+		}
+	}
+	for _, m := range g.conf.Mounts {
+		if err := g.mount(m); err != nil {
+			return err
+		}
+	}
+	if err := g.linkMounts(); err != nil {
+		return err
+	}
+	if err := g.attachEncodings(); err != nil {
+		return err
+	}
+	// Compress only now, so that the files attached as encodings are not.
+	for _, f := range g.files {
+		if err := g.compress(f); err != nil {
+			return err
+		}
+	}
+	if g.conf.Stats != nil {
+		if err := g.writeStats(); err != nil {
+			return err
+		}
+	}
+	return g.checkSizes()
+}
+
+// walk adds base and, if it is a directory, everything below it, named by
+// stripping the prefix for base.
+func (g *generator) walk(base string) error {
+	prefix := g.prefixFor(base)
+	return g.walkNamed(base, prefix, func(fname string) string {
+		return canonicFileName(fname, prefix)
+	})
+}
+
+// prefixFor returns the prefix to strip from the names below the entry base
+// of Files.
+func (g *generator) prefixFor(base string) string {
+	if prefix, ok := g.conf.Prefixes[base]; ok {
+		return filepath.ToSlash(prefix)
+	}
+	for b, prefix := range g.conf.Prefixes {
+		if filepath.Clean(b) == filepath.Clean(base) {
+			return filepath.ToSlash(prefix)
+		}
+	}
+	return g.prefix
+}
+
+// walkNamed is like walk, but names files with name. prefix is what name
+// strips, for error messages.
+func (g *generator) walkNamed(base, prefix string, name func(fname string) string) error {
+	files := []string{base}
+	for len(files) > 0 {
+		fname := files[0]
+		files = files[1:]
+		if g.ignored(fname) {
+			continue
+		}
+		children, err := g.add(fname, prefix, name)
+		if err != nil {
+			return err
+		}
+		files = append(files, children...)
+	}
+	return nil
+}
+
+// add adds the file or directory fname, named by name, and returns the
+// children of a directory that still need to be walked.
+func (g *generator) add(fname, prefix string, name func(string) string) ([]string, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, &FileError{Path: fname, Err: err}
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, &FileError{Path: fname, Err: err}
+	}
+	fpath := filepath.ToSlash(fname)
+	if g.conf.Reproducible {
+		fpath = relativePath(fname)
+	}
+	n := name(fname)
+	if fi.IsDir() {
+		g.input(fname, true)
+		fis, err := f.Readdir(0)
+		if err != nil {
+			return nil, &FileError{Path: fname, Err: err}
+		}
+		dir := &_escDir{
+			Name:           n,
+			BaseName:       path.Base(n),
+			Local:          fpath,
+			ChildFileNames: make([]string, 0, len(fis)),
+		}
+		children := make([]string, 0, len(fis))
+		for _, fi := range fis {
+			childFName := filepath.Join(fname, fi.Name())
+			children = append(children, childFName)
+			if g.ignored(childFName) {
 				continue
 			}
-			f, err := os.Open(fname)
-			if err != nil {
-				return err
-			}
-			fi, err := f.Stat()
-			if err != nil {
-				return err
+			if g.included(childFName) {
+				dir.ChildFileNames = append(dir.ChildFileNames, name(childFName))
 			}
-			fpath := filepath.ToSlash(fname)
-			n := canonicFileName(fname, prefix)
-			if fi.IsDir() {
-				fis, err := f.Readdir(0)
-				if err != nil {
-					return err
-				}
-				dir := &_escDir{
-					Name:           n,
-					BaseName:       path.Base(n),
-					Local:          fpath,
-					ChildFileNames: make([]string, 0, len(fis)),
-				}
-				for _, fi := range fis {
-					childFName := filepath.Join(fname, fi.Name())
-					files = append(files, childFName)
-					if ignoreRegexp != nil && ignoreRegexp.MatchString(childFName) {
-						continue
-					}
-					if includeRegexp == nil || includeRegexp.MatchString(childFName) {
-						dir.ChildFileNames = append(dir.ChildFileNames, canonicFileName(filepath.Join(fname, fi.Name()), prefix))
-					}
-				}
-				sort.Strings(dir.ChildFileNames)
-				directories = append(directories, dir)
-			} else if includeRegexp == nil || includeRegexp.MatchString(fname) {
-				b, err := ioutil.ReadAll(f)
-				if err != nil {
-					return errors.Wrap(err, "readAll return err")
-				}
-				if alreadyPrepared[n] {
-					return fmt.Errorf("%s, %s: duplicate Name after prefix removal", n, fpath)
-				}
-				escFile := &_escFile{
-					Name:     n,
-					BaseName: path.Base(n),
-					Data:     b,
-					Local:    fpath,
-					fileinfo: fi,
-					ModTime:  fi.ModTime().Unix(),
-				}
-				if modTime != nil {
-					escFile.ModTime = *modTime
-				}
-				if err := escFile.fillCompressed(gzipLevel); err != nil {
-					return err
-				}
-				escFiles = append(escFiles, escFile)
-				alreadyPrepared[n] = true
+		}
+		if d, ok := g.dirIndex[n]; ok {
+			// Merge directories mounted at the same name.
+			for _, c := range dir.ChildFileNames {
+				d.addChild(c)
 			}
-			f.Close()
+			return children, nil
 		}
+		if other, ok := g.prepared[n]; ok {
+			return nil, g.duplicate(n, fpath, prefix, other)
+		}
+		sort.Strings(dir.ChildFileNames)
+		g.dirs = append(g.dirs, dir)
+		g.dirIndex[n] = dir
+		g.stripped[n] = prefix
+		return children, nil
+	}
+	if !g.included(fname) {
+		return nil, nil
+	}
+	g.input(fname, false)
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, &FileError{Path: fname, Err: err}
+	}
+	if other, ok := g.taken(n); ok {
+		return nil, g.duplicate(n, fpath, prefix, other)
+	}
+	newName, tb, err := g.transform(fname, n, b)
+	if err != nil {
+		return nil, &FileError{Path: fname, Err: err}
+	}
+	transformed := newName != n || !bytes.Equal(tb, b)
+	b = tb
+	if newName != n {
+		if g.renamed == nil {
+			g.renamed = make(map[string]string)
+		}
+		g.renamed[n] = newName
+		if newName == "" {
+			return nil, nil
+		}
+		if other, ok := g.taken(newName); ok {
+			return nil, g.duplicate(newName, fpath, prefix, other)
+		}
+		n = newName
+	}
+	escFile := &_escFile{
+		Name:        n,
+		BaseName:    path.Base(n),
+		Data:        b,
+		Local:       fpath,
+		fileinfo:    fi,
+		ModTime:     fi.ModTime().Unix(),
+		transformed: transformed,
+	}
+	if g.modTime != nil {
+		escFile.ModTime = *g.modTime
 	}
+	g.files = append(g.files, escFile)
+	g.prepared[n] = fpath
+	g.stripped[n] = prefix
+	return nil, nil
+}
 
-	sort.Slice(escFiles, func(i, j int) bool { return strings.Compare(escFiles[i].Name, escFiles[j].Name) == -1 })
-	sort.Slice(directories, func(i, j int) bool { return strings.Compare(directories[i].Name, directories[j].Name) == -1 })
+// compress compresses f, unless it already is compressed.
+func (g *generator) compress(f *_escFile) error {
+	level := g.gzipLevel
+	if level != gzip.NoCompression && !g.conf.ForceCompression && isCompressed(f.Data) {
+		level = gzip.NoCompression
+		f.stored = true
+		g.stats.stored += int64(len(f.Data))
+		g.stats.storedFiles++
+	}
+	start := time.Now()
+	if err := f.fillCompressed(level); err != nil {
+		return err
+	}
+	f.compressTime = time.Since(start)
+	if !f.stored {
+		g.stats.compressed += int64(len(f.Data))
+		g.stats.compressTime += f.compressTime
+	}
+	return nil
+}
+
+// write renders the collected files and directories to out, and any shards
+// next to the output file.
+func (g *generator) write(out io.Writer) error {
+	escFiles, directories := g.sorted()
 
-	functionPrefix := ""
-	if conf.Private {
-		functionPrefix = "_esc"
+	// Unexported identifiers have an underscore after the bundle, so those
+	// of different bundles in a package cannot collide, as _escHTTPFile of
+	// bundle HTTP would with _escHTTPFile of the default bundle.
+	ident := g.conf.Bundle
+	if ident != "" {
+		ident += "_"
+	}
+	functionPrefix := g.conf.Bundle
+	if g.conf.Private {
+		functionPrefix = "_esc" + ident
+	}
+	envPrefix := "ESC_"
+	if g.conf.Bundle != "" {
+		envPrefix += strings.ToUpper(g.conf.Bundle) + "_"
 	}
 
-	buf := bytes.NewBuffer(nil)
-	tmpl.Execute(buf, templateParams{
-		Invocation:     conf.Invocation,
-		PackageName:    conf.Package,
+	invocation := g.conf.Invocation
+	if g.conf.Reproducible {
+		invocation = normalizeInvocation(invocation)
+	}
+
+	outName := "static.go"
+	if g.conf.OutputFile != "" {
+		outName = g.conf.OutputFile
+		g.outputs = append(g.outputs, outName)
+	}
+
+	params := templateParams{
+		Invocation:     invocation,
+		PackageName:    g.conf.Package,
 		FunctionPrefix: functionPrefix,
+		Bundle:         g.conf.Bundle,
+		Ident:          ident,
+		EnvPrefix:      envPrefix,
+		Handler:        g.conf.Handler,
+		LocalFallback:  g.conf.LocalFallback,
+		Precompressed:  g.conf.Precompressed,
 		Files:          escFiles,
 		Dirs:           directories,
-	})
+	}
+	if g.conf.AssetConsts {
+		params.Assets = assetConsts(escFiles)
+	}
+	if g.conf.Archive != "" {
+		params.Archive = filepath.ToSlash(g.conf.Archive)
+		if g.conf.Reproducible {
+			params.Archive = relativePath(g.conf.Archive)
+		}
+		if err := g.writeArchive(); err != nil {
+			return err
+		}
+		g.outputs = append(g.outputs, g.conf.Archive)
+	}
+	shards := g.shards()
+	if len(shards) > 1 && g.conf.Archive == "" {
+		if g.conf.OutputFile == "" {
+			return &ConfigError{Option: "ShardSize", Err: errors.New("sharding output requires an output file")}
+		}
+		params.Files = nil
+	} else {
+		shards = nil
+	}
+
+	data, err := render("file", outName, params)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
 
-	fakeOutFileName := "static.go"
-	if conf.OutputFile != "" {
-		fakeOutFileName = conf.OutputFile
+	params.ShardOf = filepath.Base(outName)
+	for i, files := range shards {
+		name := shardFileName(outName, i+1)
+		params.Files = files
+		data, err := render("shard", name, params)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(name, data, 0644); err != nil {
+			return &FileError{Path: name, Err: err}
+		}
+		g.outputs = append(g.outputs, name)
 	}
+	if g.conf.OutputFile != "" {
+		removeStaleShards(outName, len(shards)+1)
+	}
+	return nil
+}
+
+// duplicate returns the error for the file fpath, named name after stripping
+// prefix, when other already has that name.
+func (g *generator) duplicate(name, fpath, prefix, other string) error {
+	return &DuplicateNameError{
+		Name:        name,
+		Path:        fpath,
+		Other:       other,
+		Prefix:      prefix,
+		OtherPrefix: g.stripped[name],
+	}
+}
+
+// sorted sorts the collected files and directories by name and returns them.
+func (g *generator) sorted() ([]*_escFile, []*_escDir) {
+	g.relist()
+	escFiles, directories := g.files, g.dirs
+	sort.Slice(escFiles, func(i, j int) bool { return strings.Compare(escFiles[i].Name, escFiles[j].Name) == -1 })
+	sort.Slice(directories, func(i, j int) bool { return strings.Compare(directories[i].Name, directories[j].Name) == -1 })
+	return escFiles, directories
+}
 
-	data, err := imports.Process(fakeOutFileName, buf.Bytes(), nil)
+// render executes the named template and formats the result as the Go
+// source file name.
+func render(tmplName, name string, params templateParams) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.ExecuteTemplate(buf, tmplName, params); err != nil {
+		return nil, &TemplateError{Err: err}
+	}
+	data, err := format.Source(buf.Bytes())
 	if err != nil {
-		return errors.Wrap(err, "imports.Process return error")
+		return nil, &FormatError{Path: name, Err: err}
 	}
+	return data, nil
+}
 
-	fmt.Fprint(out, string(data))
+// shards groups the sorted files so that the compressed data of each group
+// stays within ShardSize, if possible.
+func (g *generator) shards() [][]*_escFile {
+	if g.conf.ShardSize <= 0 {
+		return [][]*_escFile{g.files}
+	}
+	var shards [][]*_escFile
+	var cur []*_escFile
+	var size int64
+	for _, f := range g.files {
+		n := int64(len(f.Compressed))
+		for _, e := range f.Encodings {
+			n += int64(len(e.Encoded))
+		}
+		if len(cur) > 0 && size+n > g.conf.ShardSize {
+			shards = append(shards, cur)
+			cur, size = nil, 0
+		}
+		cur = append(cur, f)
+		size += n
+	}
+	return append(shards, cur)
+}
 
-	return nil
+// shardFileName returns the name of shard i of the output file name, for
+// example static_1.go for static.go.
+func shardFileName(name string, i int) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(name, ext), i, ext)
+}
+
+// removeStaleShards removes shards of name left over from an earlier run
+// that produced more of them, starting at shard i. Only files generated by
+// esc as shards of name are removed.
+func removeStaleShards(name string, i int) {
+	for ; ; i++ {
+		shard := shardFileName(name, i)
+		if !isShard(shard, name) {
+			return
+		}
+		os.Remove(shard)
+	}
+}
+
+// shardMark returns the comment in the shards of the output file with the
+// base name name.
+func shardMark(name string) string {
+	return "\n// This file is a shard of " + name + ".\n"
+}
+
+// isShard reports whether the file shard was generated by esc as a shard of
+// the output file name.
+func isShard(shard, name string) bool {
+	b, err := ioutil.ReadFile(shard)
+	return err == nil && bytes.HasPrefix(b, []byte(`// Code generated by "esc`)) &&
+		bytes.Contains(b, []byte(shardMark(filepath.Base(name))))
+}
+
+// assetConsts returns the constants for files, making identifiers unique by
+// appending a number where needed.
+func assetConsts(files []*_escFile) []assetConst {
+	consts := make([]assetConst, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		ident := assetIdent(f.Name)
+		for i := 2; seen[ident]; i++ {
+			ident = fmt.Sprintf("%s_%d", assetIdent(f.Name), i)
+		}
+		seen[ident] = true
+		consts = append(consts, assetConst{Ident: ident, Name: f.Name})
+	}
+	return consts
+}
+
+var identSplitRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// commonInitialisms are upper-cased in identifiers, following golint.
+var commonInitialisms = map[string]bool{
+	"API": true, "ASCII": true, "CSS": true, "CSV": true, "EOF": true,
+	"GIF": true, "HTML": true, "HTTP": true, "ICO": true, "ID": true,
+	"JPG": true, "JS": true, "JSON": true, "PDF": true, "PNG": true,
+	"SQL": true, "SVG": true, "TTF": true, "UI": true, "URL": true,
+	"XML": true,
+}
+
+// assetIdent returns the identifier of the constant for the embedded file
+// name: its words in CamelCase after Asset, for example AssetCSSMainCSS for
+// "/css/main.css".
+func assetIdent(name string) string {
+	ident := "Asset"
+	for _, word := range identSplitRegexp.Split(name, -1) {
+		if word == "" {
+			continue
+		}
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			ident += upper
+		} else {
+			ident += upper[:1] + word[1:]
+		}
+	}
+	return ident
 }
 
 func canonicFileName(fname, prefix string) string {
 	fpath := filepath.ToSlash(fname)
-	return path.Join("/", strings.TrimPrefix(fpath, prefix))
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" && (fpath == prefix || strings.HasPrefix(fpath, prefix+"/")) {
+		fpath = fpath[len(prefix):]
+	}
+	return path.Join("/", fpath)
+}
+
+// relativePath returns fname in slash form, relative to the working directory
+// if it is absolute.
+func relativePath(fname string) string {
+	if filepath.IsAbs(fname) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, fname); err == nil {
+				fname = rel
+			}
+		}
+	}
+	return filepath.ToSlash(fname)
+}
+
+// normalizeInvocation rewrites the arguments of an invocation string so that
+// it is the same on every machine. Only arguments naming existing absolute
+// paths are rewritten, so regular expressions are left alone.
+func normalizeInvocation(invocation string) string {
+	normalize := func(arg string) string {
+		if !filepath.IsAbs(arg) {
+			return arg
+		}
+		if _, err := os.Stat(arg); err != nil {
+			return arg
+		}
+		return relativePath(arg)
+	}
+	args := strings.Fields(invocation)
+	for i, arg := range args {
+		if eq := strings.IndexByte(arg, '='); strings.HasPrefix(arg, "-") && eq >= 0 {
+			args[i] = arg[:eq+1] + normalize(arg[eq+1:])
+		} else {
+			args[i] = normalize(arg)
+		}
+	}
+	return strings.Join(args, " ")
 }
 
 func (f *_escFile) fillCompressed(gzipLevel int) error {
@@ -223,23 +825,16 @@ func (f *_escFile) fillCompressed(gzipLevel int) error {
 	if err != nil {
 		return err
 	}
+	// Pin the header so the output does not depend on the machine.
+	gw.Header = gzip.Header{OS: 255}
 	if _, err := gw.Write(f.Data); err != nil {
 		return err
 	}
 	if err := gw.Close(); err != nil {
 		return err
 	}
-	var b bytes.Buffer
-	b64 := base64.NewEncoder(base64.StdEncoding, &b)
-	b64.Write(buf.Bytes())
-	b64.Close()
-	res := "\n"
-	chunk := make([]byte, 80)
-	for n, _ := b.Read(chunk); n > 0; n, _ = b.Read(chunk) {
-		res += string(chunk[0:n]) + "\n"
-	}
-
-	f.Compressed = res
+	f.gzipped = buf.Bytes()
+	f.Compressed = base64Lines(f.gzipped)
 	return nil
 
 }
@@ -252,66 +847,347 @@ package {{.PackageName}}
 import (
 	"bytes"
 	"compress/gzip"
+{{- if .Archive }}
+	"encoding/binary"
+	"encoding/json"
+{{- else }}
 	"encoding/base64"
+{{- end }}
+	"fmt"
+{{- if .Handler }}
+	"html"
+	"html/template"
+{{- end }}
+	"io"
 	"io/ioutil"
+{{- if and .Handler .Precompressed }}
+	"mime"
+{{- end }}
 	"net/http"
+{{- if .Handler }}
+	"net/url"
+{{- end }}
 	"os"
 	"path"
+	"path/filepath"
+{{- if .Handler }}
+	"sort"
+{{- if .Precompressed }}
+	"strconv"
+{{- end }}
+{{- end }}
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type _escLocalFS struct{}
+type _esc{{.Ident}}LocalFS struct{}
 
-var _escLocal _escLocalFS
+var _esc{{.Ident}}Local _esc{{.Ident}}LocalFS
 
-type _escStaticFS struct{}
+type _esc{{.Ident}}StaticFS struct{}
 
-var _escStatic _escStaticFS
+var _esc{{.Ident}}Static _esc{{.Ident}}StaticFS
 
-type _escDirectory struct {
+type _esc{{.Ident}}Directory struct {
 	fs   http.FileSystem
 	name string
 }
 
-type _escFile struct {
+type _esc{{.Ident}}File struct {
+{{- if .Archive }}
+	offset     int64
+	length     int64
+{{- else }}
 	compressed string
+{{- end }}
 	size       int64
 	modtime    int64
 	local      string
 	isDir      bool
+	fullName   string
+{{- if .Precompressed }}
+	// encodings are the precompressed encodings of the file{{ if .Archive }}, as offset and length{{ end }}.
+	encodings map[string]{{ if .Archive }}[2]int64{{ else }}string{{ end }}
+{{- end }}
 
 	once sync.Once
-	data []byte
-	name string
+	// ready is set once data holds the whole inflated file.
+	ready uint32
+	data  []byte
+	name  string
+
+	// mu guards the inflation of the file by lazy readers: gr inflates it
+	// into partial until all of it is read or err is set.
+	mu      sync.Mutex
+	gr      *gzip.Reader
+	partial []byte
+	err     error
+}
+
+// _esc{{.Ident}}LocalRoot, if set, is the directory local paths are resolved against.
+var _esc{{.Ident}}LocalRoot string
+
+// {{.FunctionPrefix}}SetLocalRoot sets the directory that the local filesystem resolves
+// paths against, overriding the {{.EnvPrefix}}LOCAL_ROOT environment variable. If neither
+// is set, paths are relative to the current working directory. It should be
+// called before the local filesystem is used.
+func {{.FunctionPrefix}}SetLocalRoot(root string) {
+	_esc{{.Ident}}LocalRoot = root
+}
+
+// _esc{{.Ident}}LocalPath resolves the slash-separated local path against the local root.
+func _esc{{.Ident}}LocalPath(local string) string {
+	local = filepath.FromSlash(local)
+	root := _esc{{.Ident}}LocalRoot
+	if root == "" {
+		root = os.Getenv("{{.EnvPrefix}}LOCAL_ROOT")
+	}
+	if root != "" && !filepath.IsAbs(local) {
+		local = filepath.Join(root, local)
+	}
+	return local
 }
 
-func (_escLocalFS) Open(name string) (http.File, error) {
-	f, present := _escData[path.Clean(name)]
+{{- if .Precompressed }}
+
+// _esc{{.Ident}}Encodings are the precompressed encodings served, in order of preference,
+// with the suffixes of their files on disk.
+var _esc{{.Ident}}Encodings = [][2]string{{"{{"}}"br", ".br"}, {"gzip", ".gz"{{"}}"}}
+{{- end }}
+
+// local returns the on-disk path for name.
+{{- if .LocalFallback }} Names not present at generation
+// time are resolved relative to their nearest embedded parent directory.
+{{- end }}
+{{- /* Archives are not loaded in local mode, so use the files generated with. */}}
+{{- $data := "Data" }}{{ if .Archive }}{{ $data = "LocalData" }}{{ end }}
+func (_esc{{.Ident}}LocalFS) local(name string) (string, bool) {
+	name, ok := _esc{{.Ident}}Clean(name)
+	if !ok {
+		return "", false
+	}
+{{- if .LocalFallback }}
+	rel := ""
+	for {
+		if f, present := _esc{{.Ident}}{{$data}}[name]; present {
+			if f.local == "" || rel != "" && !f.isDir {
+				return "", false
+			}
+			return f.local + rel, true
+		}
+		if name == "/" {
+			return "", false
+		}
+		rel = "/" + path.Base(name) + rel
+		name = path.Dir(name)
+	}
+{{- else }}
+	f, present := _esc{{.Ident}}{{$data}}[name]
+{{- if .Precompressed }}
 	if !present {
+		// The files of precompressed encodings are next to the file they encode.
+		for _, enc := range _esc{{.Ident}}Encodings {
+			if base := strings.TrimSuffix(name, enc[1]); base != name {
+				if f, present := _esc{{.Ident}}{{$data}}[base]; present && f.local != "" {
+					if _, ok := f.encodings[enc[0]]; ok {
+						return f.local + enc[1], true
+					}
+				}
+			}
+		}
+	}
+{{- end }}
+	if !present || f.local == "" {
+		return "", false
+	}
+	return f.local, true
+{{- end }}
+}
+
+func (fs _esc{{.Ident}}LocalFS) Open(name string) (http.File, error) {
+	local, ok := fs.local(name)
+	if !ok {
 		return nil, os.ErrNotExist
 	}
-	return os.Open(f.local)
+	return os.Open(_esc{{.Ident}}LocalPath(local))
+}
+{{- if .Archive }}
+
+// _esc{{.Ident}}ArchiveMagic ends every esc archive.
+const _esc{{.Ident}}ArchiveMagic = "escarch1"
+
+// _esc{{.Ident}}ArchiveDefault is the archive path given when the file was generated.
+const _esc{{.Ident}}ArchiveDefault = "{{ .Archive }}"
+
+// _esc{{.Ident}}ArchiveName, if set, overrides the archive path.
+var _esc{{.Ident}}ArchiveName string
+
+var _esc{{.Ident}}Archive struct {
+	once sync.Once
+	err  error
+	r    io.ReaderAt
+}
+
+type _esc{{.Ident}}ArchiveIndex struct {
+	Bundle  string                           ` + "`" + `json:"bundle"` + "`" + `
+	Entries []_esc{{.Ident}}ArchiveEntry ` + "`" + `json:"entries"` + "`" + `
+}
+
+type _esc{{.Ident}}ArchiveEntry struct {
+	Name     string   ` + "`" + `json:"name"` + "`" + `
+	Local    string   ` + "`" + `json:"local"` + "`" + `
+	Size     int64    ` + "`" + `json:"size,omitempty"` + "`" + `
+	ModTime  int64    ` + "`" + `json:"modtime,omitempty"` + "`" + `
+	Offset   int64    ` + "`" + `json:"offset,omitempty"` + "`" + `
+	Length   int64    ` + "`" + `json:"length,omitempty"` + "`" + `
+	IsDir    bool     ` + "`" + `json:"dir,omitempty"` + "`" + `
+	Children []string ` + "`" + `json:"children,omitempty"` + "`" + `
+{{- if .Precompressed }}
+	Encodings map[string][2]int64 ` + "`" + `json:"encodings,omitempty"` + "`" + `
+{{- end }}
+}
+
+// {{.FunctionPrefix}}SetArchivePath sets the path of the asset archive, overriding the
+// {{.EnvPrefix}}ARCHIVE environment variable. The path is only used if no archive is
+// appended to the executable. Relative paths are resolved like local paths.
+// It should be called before the assets are used.
+func {{.FunctionPrefix}}SetArchivePath(name string) {
+	_esc{{.Ident}}ArchiveName = name
+}
+
+// _esc{{.Ident}}LoadArchive reads the archive index into _esc{{.Ident}}Data and _esc{{.Ident}}Dirs the
+// first time it is called.
+func _esc{{.Ident}}LoadArchive() error {
+	_esc{{.Ident}}Archive.once.Do(func() {
+		if exe, err := os.Executable(); err == nil {
+			if _esc{{.Ident}}OpenArchive(exe) == nil {
+				return
+			}
+		}
+		name := _esc{{.Ident}}ArchiveName
+		if name == "" {
+			name = os.Getenv("{{.EnvPrefix}}ARCHIVE")
+		}
+		if name == "" {
+			name = _esc{{.Ident}}ArchiveDefault
+		}
+		_esc{{.Ident}}Archive.err = _esc{{.Ident}}OpenArchive(_esc{{.Ident}}LocalPath(name))
+	})
+	return _esc{{.Ident}}Archive.err
+}
+
+// _esc{{.Ident}}OpenArchive mounts the archive found at the end of the named file.
+func _esc{{.Ident}}OpenArchive(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	if err := _esc{{.Ident}}ReadArchive(f); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+func _esc{{.Ident}}ReadArchive(f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// Archives of other bundles may have been appended before or after
+	// this one, so walk them back from the end of the file.
+	const trailerLen = 24
+	var index _esc{{.Ident}}ArchiveIndex
+	var start int64
+	for end := fi.Size(); ; end = start {
+		if end < trailerLen {
+			return fmt.Errorf("no esc archive for bundle %q", "{{.Bundle}}")
+		}
+		var trailer [trailerLen]byte
+		if _, err := f.ReadAt(trailer[:], end-trailerLen); err != nil {
+			return err
+		}
+		if string(trailer[16:]) != _esc{{.Ident}}ArchiveMagic {
+			return fmt.Errorf("no esc archive for bundle %q", "{{.Bundle}}")
+		}
+		indexOff := int64(binary.LittleEndian.Uint64(trailer[0:8]))
+		indexLen := int64(binary.LittleEndian.Uint64(trailer[8:16]))
+		start = end - trailerLen - indexLen - indexOff
+		if indexOff < 0 || indexLen < 0 || start < 0 {
+			return fmt.Errorf("corrupt esc archive")
+		}
+		index = _esc{{.Ident}}ArchiveIndex{}
+		if err := json.NewDecoder(io.NewSectionReader(f, start+indexOff, indexLen)).Decode(&index); err != nil {
+			return err
+		}
+		if index.Bundle == "{{.Bundle}}" {
+			break
+		}
+	}
+	for _, e := range index.Entries {
+		_esc{{.Ident}}Data[e.Name] = &_esc{{.Ident}}File{
+			offset:  start + e.Offset,
+			length:  e.Length,
+			size:    e.Size,
+			modtime: e.ModTime,
+			local:   e.Local,
+			isDir:   e.IsDir,
+			name:    path.Base(e.Name),
+		}
+{{- if .Precompressed }}
+		for enc, span := range e.Encodings {
+			span[0] += start
+			e.Encodings[enc] = span
+		}
+		_esc{{.Ident}}Data[e.Name].encodings = e.Encodings
+{{- end }}
+		if e.IsDir {
+			_esc{{.Ident}}Data[e.Name].fullName = e.Name
+			_esc{{.Ident}}Dirs[e.Name] = e.Children
+		}
+	}
+	_esc{{.Ident}}Archive.r = f
+	return nil
 }
+{{- end }}
 
-func (_escStaticFS) prepare(name string) (*_escFile, error) {
-	f, present := _escData[path.Clean(name)]
+// lookup returns the named file without inflating it.
+func (_esc{{.Ident}}StaticFS) lookup(name string) (*_esc{{.Ident}}File, error) {
+{{- if .Archive }}
+	if err := _esc{{.Ident}}LoadArchive(); err != nil {
+		return nil, err
+	}
+{{- end }}
+	name, ok := _esc{{.Ident}}Clean(name)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	f, present := _esc{{.Ident}}Data[name]
 	if !present {
 		return nil, os.ErrNotExist
 	}
-	var err error
+	return f, nil
+}
+
+// prepare returns the named file, inflated.
+func (fs _esc{{.Ident}}StaticFS) prepare(name string) (*_esc{{.Ident}}File, error) {
+	f, err := fs.lookup(name)
+	if err != nil {
+		return nil, err
+	}
 	f.once.Do(func() {
-		f.name = path.Base(name)
-		if f.size == 0 {
-			return
-		}
-		var gr *gzip.Reader
-		b64 := base64.NewDecoder(base64.StdEncoding, bytes.NewBufferString(f.compressed))
-		gr, err = gzip.NewReader(b64)
-		if err != nil {
-			return
+		if f.size != 0 {
+			var gr *gzip.Reader
+			if gr, err = f.gzipReader(); err != nil {
+				return
+			}
+			if f.data, err = ioutil.ReadAll(gr); err != nil {
+				return
+			}
 		}
-		f.data, err = ioutil.ReadAll(gr)
+		atomic.StoreUint32(&f.ready, 1)
 	})
 	if err != nil {
 		return nil, err
@@ -319,80 +1195,238 @@ func (_escStaticFS) prepare(name string) (*_escFile, error) {
 	return f, nil
 }
 
-func (fs _escStaticFS) Open(name string) (http.File, error) {
-	f, err := fs.prepare(name)
+// gzipReader returns a reader inflating the contents of f.
+func (f *_esc{{.Ident}}File) gzipReader() (*gzip.Reader, error) {
+{{- if .Archive }}
+	return gzip.NewReader(io.NewSectionReader(_esc{{.Ident}}Archive.r, f.offset, f.length))
+{{- else }}
+	b64 := base64.NewDecoder(base64.StdEncoding, bytes.NewBufferString(f.compressed))
+	return gzip.NewReader(b64)
+{{- end }}
+}
+
+func (fs _esc{{.Ident}}StaticFS) Open(name string) (http.File, error) {
+	f, err := fs.lookup(name)
 	if err != nil {
 		return nil, err
 	}
 	return f.File()
 }
 
-func (dir _escDirectory) Open(name string) (http.File, error) {
-	return dir.fs.Open(dir.name + name)
+// Open opens name below the directory. Names leading out of it are not found.
+func (dir _esc{{.Ident}}Directory) Open(name string) (http.File, error) {
+	name, ok := _esc{{.Ident}}Clean(name)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return dir.fs.Open(path.Join("/", dir.name, name))
+}
+
+// _esc{{.Ident}}Clean returns the slash-separated name, relative to a root, as a clean
+// absolute path. It reports false if the name leads out of the root or holds
+// characters that are not allowed in paths.
+func _esc{{.Ident}}Clean(name string) (string, bool) {
+	if strings.IndexByte(name, 0) >= 0 || filepath.Separator != '/' && strings.ContainsRune(name, filepath.Separator) {
+		return "", false
+	}
+	rel := path.Clean(strings.TrimLeft(name, "/"))
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return path.Join("/", rel), true
+}
+
+// _esc{{.Ident}}HTTPFile is an open embedded file.
+type _esc{{.Ident}}HTTPFile struct {
+	io.ReadSeeker
+	*_esc{{.Ident}}File
+	// dirPos is the number of directory entries already read.
+	dirPos int
 }
 
-func (f *_escFile) File() (http.File, error) {
-	type httpFile struct {
-		*bytes.Reader
-		*_escFile
+// File opens f. A file that is not inflated yet is inflated as it is read,
+// so reading a range near its start does not inflate all of it.
+func (f *_esc{{.Ident}}File) File() (http.File, error) {
+	var r io.ReadSeeker
+	if f.isDir || f.size == 0 || atomic.LoadUint32(&f.ready) == 1 {
+		r = bytes.NewReader(f.data)
+	} else {
+		r = &_esc{{.Ident}}LazyReader{f: f}
 	}
-	return &httpFile{
-		Reader:   bytes.NewReader(f.data),
-		_escFile: f,
+	return &_esc{{.Ident}}HTTPFile{
+		ReadSeeker: r,
+		_esc{{.Ident}}File: f,
 	}, nil
 }
 
-func (f *_escFile) Close() error {
+// _esc{{.Ident}}LazyReader reads a file, inflating only as much of it as is needed.
+// Readers of a file share what is inflated, and once all of it is, the data
+// is kept for later opens.
+type _esc{{.Ident}}LazyReader struct {
+	f   *_esc{{.Ident}}File
+	pos int64
+}
+
+func (r *_esc{{.Ident}}LazyReader) Read(p []byte) (int, error) {
+	if r.pos >= r.f.size {
+		return 0, io.EOF
+	}
+	buf, err := r.f.inflate(r.pos + int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, buf[r.pos:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *_esc{{.Ident}}LazyReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.f.size
+	default:
+		return 0, fmt.Errorf(" escFile.Seek: invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf(" escFile.Seek: negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// inflate inflates the file up to offset n, and returns what is inflated.
+func (f *_esc{{.Ident}}File) inflate(n int64) ([]byte, error) {
+	if atomic.LoadUint32(&f.ready) == 1 {
+		return f.data, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if n > f.size {
+		n = f.size
+	}
+	if int64(len(f.partial)) >= n {
+		return f.partial, nil
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.gr == nil {
+		if f.gr, f.err = f.gzipReader(); f.err != nil {
+			return nil, f.err
+		}
+	}
+	for int64(len(f.partial)) < n {
+		if len(f.partial) == cap(f.partial) {
+			// Grow the buffer as the file is read, up to its size.
+			c := 2*int64(cap(f.partial)) + 512
+			if c > f.size {
+				c = f.size
+			}
+			buf := make([]byte, len(f.partial), c)
+			copy(buf, f.partial)
+			f.partial = buf
+		}
+		m, err := f.gr.Read(f.partial[len(f.partial):cap(f.partial)])
+		f.partial = f.partial[:len(f.partial)+m]
+		if err == io.EOF && int64(len(f.partial)) == f.size {
+			break
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			f.err = err
+			return nil, err
+		}
+	}
+	if int64(len(f.partial)) == f.size {
+		f.once.Do(func() {
+			f.data = f.partial
+			atomic.StoreUint32(&f.ready, 1)
+		})
+		f.gr, f.partial = nil, nil
+		return f.data, nil
+	}
+	return f.partial, nil
+}
+
+func (f *_esc{{.Ident}}File) Close() error {
 	return nil
 }
 
-func (f *_escFile) Readdir(count int) ([]os.FileInfo, error) {
+// Readdir behaves like os.File.Readdir: if count is positive, it returns at
+// most count entries following those already read, and io.EOF at the end.
+func (f *_esc{{.Ident}}HTTPFile) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := f.entries()
+	if err != nil {
+		return nil, err
+	}
+	fis = fis[f.dirPos:]
+	if count <= 0 {
+		f.dirPos += len(fis)
+		return fis, nil
+	}
+	if len(fis) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(fis) {
+		count = len(fis)
+	}
+	f.dirPos += count
+	return fis[:count], nil
+}
+
+// entries returns the entries of the directory f, sorted by name.
+func (f *_esc{{.Ident}}File) entries() ([]os.FileInfo, error) {
 	if !f.isDir {
 		return nil, fmt.Errorf(" escFile.Readdir: '%s' is not directory", f.name)
 	}
 
-	fis, ok := _escDirs[f.local]
+	names, ok := _esc{{.Ident}}Dirs[f.fullName]
 	if !ok {
 		return nil, fmt.Errorf(" escFile.Readdir: '%s' is directory, but we have no info about content of this dir, local=%s", f.name, f.local)
 	}
-	limit := count
-	if count <= 0 || limit > len(fis) {
-		limit = len(fis)
-	}
-
-	if len(fis) == 0 && count > 0 {
-		return nil, io.EOF
+	fis := make([]os.FileInfo, 0, len(names))
+	for _, name := range names {
+		if fi, present := _esc{{.Ident}}Data[name]; present {
+			fis = append(fis, fi)
+		}
 	}
-
-	return fis[0:limit], nil
+	return fis, nil
 }
 
 
-func (f *_escFile) Stat() (os.FileInfo, error) {
+func (f *_esc{{.Ident}}File) Stat() (os.FileInfo, error) {
 	return f, nil
 }
 
-func (f *_escFile) Name() string {
+func (f *_esc{{.Ident}}File) Name() string {
 	return f.name
 }
 
-func (f *_escFile) Size() int64 {
+func (f *_esc{{.Ident}}File) Size() int64 {
 	return f.size
 }
 
-func (f *_escFile) Mode() os.FileMode {
-	return 0
+func (f *_esc{{.Ident}}File) Mode() os.FileMode {
+	if f.isDir {
+		return os.ModeDir | 0555
+	}
+	return 0444
 }
 
-func (f *_escFile) ModTime() time.Time {
+func (f *_esc{{.Ident}}File) ModTime() time.Time {
 	return time.Unix(f.modtime, 0)
 }
 
-func (f *_escFile) IsDir() bool {
+func (f *_esc{{.Ident}}File) IsDir() bool {
 	return f.isDir
 }
 
-func (f *_escFile) Sys() interface{} {
+func (f *_esc{{.Ident}}File) Sys() interface{} {
 	return f
 }
 
@@ -400,25 +1434,25 @@ func (f *_escFile) Sys() interface{} {
 // the filesystem's contents are instead used.
 func {{.FunctionPrefix}}FS(useLocal bool) http.FileSystem {
 	if useLocal {
-		return _escLocal
+		return _esc{{.Ident}}Local
 	}
-	return _escStatic
+	return _esc{{.Ident}}Static
 }
 
 // {{.FunctionPrefix}}Dir returns a http.Filesystem for the embedded assets on a given prefix dir.
 // If useLocal is true, the filesystem's contents are instead used.
 func {{.FunctionPrefix}}Dir(useLocal bool, name string) http.FileSystem {
 	if useLocal {
-		return _escDirectory{fs: _escLocal, name: name}
+		return _esc{{.Ident}}Directory{fs: _esc{{.Ident}}Local, name: name}
 	}
-	return _escDirectory{fs: _escStatic, name: name}
+	return _esc{{.Ident}}Directory{fs: _esc{{.Ident}}Static, name: name}
 }
 
 // {{.FunctionPrefix}}FSByte returns the named file from the embedded assets. If useLocal is
 // true, the filesystem's contents are instead used.
 func {{.FunctionPrefix}}FSByte(useLocal bool, name string) ([]byte, error) {
 	if useLocal {
-		f, err := _escLocal.Open(name)
+		f, err := _esc{{.Ident}}Local.Open(name)
 		if err != nil {
 			return nil, err
 		}
@@ -426,7 +1460,7 @@ func {{.FunctionPrefix}}FSByte(useLocal bool, name string) ([]byte, error) {
 		_ = f.Close()
 		return b, err
 	}
-	f, err := _escStatic.prepare(name)
+	f, err := _esc{{.Ident}}Static.prepare(name)
 	if err != nil {
 		return nil, err
 	}
@@ -453,34 +1487,373 @@ func {{.FunctionPrefix}}FSMustString(useLocal bool, name string) string {
 	return string({{.FunctionPrefix}}FSMustByte(useLocal, name))
 }
 
-var _escData = map[string]*_escFile{
+{{- if .Handler }}
+
+// {{.FunctionPrefix}}HandlerOptions configures {{.FunctionPrefix}}Handler.
+type {{.FunctionPrefix}}HandlerOptions struct {
+	// Listing, if set, renders directory listings. It is executed with a
+	// {{.FunctionPrefix}}Listing. Otherwise a list of links is rendered.
+	Listing *template.Template
+	// NoListing, if true, answers requests for directories with 404 Not Found.
+	NoListing bool
+	// Index are the names of the files served for a directory instead of a
+	// listing, tried in order. If nil, index.html is tried.
+	Index []string
+	// NoIndex, if true, always lists directories.
+	NoIndex bool
+}
+
+// {{.FunctionPrefix}}Listing is the data a directory listing is rendered from.
+type {{.FunctionPrefix}}Listing struct {
+	// Path is the path of the directory, ending in a slash.
+	Path string
+	// Entries are the files and directories in it, sorted by name.
+	Entries []os.FileInfo
+}
+
+// {{.FunctionPrefix}}Handler returns a http.Handler serving the files of fs, which is
+// usually from {{.FunctionPrefix}}FS or {{.FunctionPrefix}}Dir. Directory listings are rendered as set by
+// opts, which may be nil.
+func {{.FunctionPrefix}}Handler(fs http.FileSystem, opts *{{.FunctionPrefix}}HandlerOptions) http.Handler {
+	h := &_esc{{.Ident}}Server{fs: fs}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+type _esc{{.Ident}}Server struct {
+	fs   http.FileSystem
+	opts {{.FunctionPrefix}}HandlerOptions
+}
+
+func (h *_esc{{.Ident}}Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upath := r.URL.Path
+	if !strings.HasPrefix(upath, "/") {
+		upath = "/" + upath
+	}
+	name := path.Clean(upath)
+	f, err := h.fs.Open(name)
+	if err != nil {
+		_esc{{.Ident}}Error(w, err)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		_esc{{.Ident}}Error(w, err)
+		return
+	}
+	if !fi.IsDir() {
+		if strings.HasSuffix(upath, "/") {
+			_esc{{.Ident}}Redirect(w, r, "../"+path.Base(name))
+			return
+		}
+		h.serveFile(w, r, name, f, fi)
+		return
+	}
+	index, ifi := h.index(name)
+	if index != nil {
+		defer index.Close()
+	} else if h.opts.NoListing {
+		http.NotFound(w, r)
+		return
+	}
+	if !strings.HasSuffix(upath, "/") {
+		_esc{{.Ident}}Redirect(w, r, path.Base(upath)+"/")
+		return
+	}
+	if index != nil {
+		h.serveFile(w, r, path.Join(name, ifi.Name()), index, ifi)
+		return
+	}
+	h.serveListing(w, name, f)
+}
+
+// index opens the index file of the directory name, if it has one.
+func (h *_esc{{.Ident}}Server) index(name string) (http.File, os.FileInfo) {
+	if h.opts.NoIndex {
+		return nil, nil
+	}
+	names := h.opts.Index
+	if names == nil {
+		names = []string{"index.html"}
+	}
+	for _, index := range names {
+		f, err := h.fs.Open(path.Join(name, index))
+		if err != nil {
+			continue
+		}
+		if fi, err := f.Stat(); err == nil && !fi.IsDir() {
+			return f, fi
+		}
+		f.Close()
+	}
+	return nil, nil
+}
+{{- if not .Precompressed }}
+
+// serveFile serves the file f, named name.
+func (h *_esc{{.Ident}}Server) serveFile(w http.ResponseWriter, r *http.Request, name string, f http.File, fi os.FileInfo) {
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+}
+{{- end }}
+
+{{- if .Precompressed }}
+
+// serveFile serves the file f, precompressed if the client accepts an encoding
+// it has.
+func (h *_esc{{.Ident}}Server) serveFile(w http.ResponseWriter, r *http.Request, name string, f http.File, fi os.FileInfo) {
+	var content io.ReadSeeker = f
+	for _, enc := range _esc{{.Ident}}Encodings {
+		rs, c := h.encoded(name, f, enc)
+		if rs == nil {
+			continue
+		}
+		if c != nil {
+			defer c.Close()
+		}
+		w.Header().Set("Vary", "Accept-Encoding")
+		if !_esc{{.Ident}}Accepts(r.Header.Get("Accept-Encoding"), enc[0]) {
+			continue
+		}
+		ctype := mime.TypeByExtension(path.Ext(name))
+		if ctype == "" {
+			var buf [512]byte
+			n, _ := io.ReadFull(f, buf[:])
+			ctype = http.DetectContentType(buf[:n])
+		}
+		w.Header().Set("Content-Type", ctype)
+		w.Header().Set("Content-Encoding", enc[0])
+		content = rs
+		break
+	}
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), content)
+}
+
+// encoded returns the file f, named name, in the encoding enc if it is
+// precompressed in it, and what to close when done. Embedded files carry
+// their encodings, while local files have them next to them on disk.
+func (h *_esc{{.Ident}}Server) encoded(name string, f http.File, enc [2]string) (io.ReadSeeker, io.Closer) {
+	if ef, ok := f.(*_esc{{.Ident}}HTTPFile); ok {
+		return ef.encoded(enc[0]), nil
+	}
+	ef, err := h.fs.Open(name + enc[1])
+	if err != nil {
+		return nil, nil
+	}
+	if fi, err := ef.Stat(); err != nil || fi.IsDir() {
+		ef.Close()
+		return nil, nil
+	}
+	return ef, ef
+}
+
+// encoded returns the contents of f in the precompressed encoding enc, or nil.
+func (f *_esc{{.Ident}}File) encoded(enc string) io.ReadSeeker {
+{{- if .Archive }}
+	span, ok := f.encodings[enc]
+	if !ok {
+		return nil
+	}
+	return io.NewSectionReader(_esc{{.Ident}}Archive.r, span[0], span[1])
+{{- else }}
+	s, ok := f.encodings[enc]
+	if !ok {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	return bytes.NewReader(b)
+{{- end }}
+}
+
+// _esc{{.Ident}}Accepts reports whether the Accept-Encoding header accept allows enc.
+func _esc{{.Ident}}Accepts(accept, enc string) bool {
+	star := false
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		q := 1.0
+		for _, p := range params[1:] {
+			if p = strings.TrimSpace(p); strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		switch name := strings.TrimSpace(params[0]); {
+		case strings.EqualFold(name, enc):
+			return q > 0
+		case name == "*":
+			star = q > 0
+		}
+	}
+	return star
+}
+{{- end }}
+
+func (h *_esc{{.Ident}}Server) serveListing(w http.ResponseWriter, name string, f http.File) {
+	fis, err := f.Readdir(-1)
+	if err != nil {
+		http.Error(w, "Error reading directory", http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+	listing := {{.FunctionPrefix}}Listing{Path: name, Entries: fis}
+	if name != "/" {
+		listing.Path += "/"
+	}
+	var buf bytes.Buffer
+	if h.opts.Listing != nil {
+		if err := h.opts.Listing.Execute(&buf, listing); err != nil {
+			http.Error(w, "Error rendering directory", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		fmt.Fprintf(&buf, "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<title>%s</title>\n<pre>\n", html.EscapeString(listing.Path))
+		for _, fi := range listing.Entries {
+			name, size := fi.Name(), fmt.Sprint(fi.Size())
+			if fi.IsDir() {
+				name, size = name+"/", "-"
+			}
+			pad := 50 - len(name)
+			if pad < 0 {
+				pad = 0
+			}
+			// Embedded directories have no modification time.
+			modtime := "-"
+			if t := fi.ModTime(); !t.IsZero() && t.Unix() != 0 {
+				modtime = t.UTC().Format("2006-01-02 15:04")
+			}
+			u := url.URL{Path: name}
+			fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>%*s %16s %10s\n", u.String(), html.EscapeString(name),
+				pad, "", modtime, size)
+		}
+		fmt.Fprintf(&buf, "</pre>\n")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// _esc{{.Ident}}Redirect redirects to the relative path newPath, keeping the query.
+func _esc{{.Ident}}Redirect(w http.ResponseWriter, r *http.Request, newPath string) {
+	if q := r.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+	w.Header().Set("Location", newPath)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+// _esc{{.Ident}}Error answers a request for a file that failed to open with err.
+func _esc{{.Ident}}Error(w http.ResponseWriter, err error) {
+	switch {
+	case os.IsNotExist(err):
+		http.Error(w, "404 page not found", http.StatusNotFound)
+	case os.IsPermission(err):
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+	}
+}
+{{- end }}
+
+{{- with .Assets }}
+
+// Names of the embedded files.
+const (
+{{- range . }}
+	{{ $.FunctionPrefix }}{{ .Ident }} = "{{ .Name }}"
+{{- end }}
+)
+{{- end }}
+
+{{- if .Archive }}
+
+var _esc{{.Ident}}Data = map[string]*_esc{{.Ident}}File{}
+
+var _esc{{.Ident}}Dirs = map[string][]string{}
+
+// _esc{{.Ident}}LocalData holds the local paths of the files embedded when the file was
+// generated, so that the local filesystem works without an archive.
+var _esc{{.Ident}}LocalData = map[string]*_esc{{.Ident}}File{
+{{- range .Files }}
+	"{{ .Name }}": {
+		local: "{{ .Local }}",
+{{- with .Encodings }}
+		encodings: map[string][2]int64{
+{{- range . }}
+			"{{ .Name }}": {},
+{{- end }}
+		},
+{{- end }}
+	},
+{{- end }}
+{{- range .Dirs }}
+	"{{ .Name }}": {
+		local: ` + "`" + `{{ .Local }}` + "`" + `,
+		isDir: true,
+	},
+{{- end }}
+}
+{{- else }}
+
+var _esc{{.Ident}}Data = map[string]*_esc{{.Ident}}File{
 {{ range .Files }}
 	"{{ .Name }}": {
-		name:    "{{ .BaseName }}",
-		local:   "{{ .Local }}",
-		size:    {{ .Data | len  }},
-		modtime: {{ .ModTime }},
-		compressed: ` + "`" + `{{ .Compressed }}` + "`" + `,
+		{{- template "entry" . }}
 	},
 {{ end -}}
 {{ range .Dirs }}
 	"{{ .Name }}": {
-		name:  "{{ .BaseName }}",
-		local: ` + "`" + `{{ .Local }}` + "`" + `,
-		isDir: true,
+		name:     "{{ .BaseName }}",
+		local:    ` + "`" + `{{ .Local }}` + "`" + `,
+		isDir:    true,
+		fullName: "{{ .Name }}",
 	},
   {{ end }}
 }
 
-var _escDirs = map[string][]os.FileInfo{
+var _esc{{.Ident}}Dirs = map[string][]string{
   {{ range .Dirs }}
-	"{{ .Local }}": {
+	"{{ .Name }}": {
 		{{ range .ChildFileNames -}}
-		_escData["{{.}}"],
+		"{{.}}",
 		{{ end }}
 	},
   {{ end }}
 }
-
+{{- end }}
+{{- define "entry" }}
+		name:    "{{ .BaseName }}",
+		local:   "{{ .Local }}",
+		size:    {{ .Data | len  }},
+		modtime: {{ .ModTime }},
+		compressed: ` + "`" + `{{ .Compressed }}` + "`" + `,
+{{- with .Encodings }}
+		encodings: map[string]string{
+{{- range . }}
+			"{{ .Name }}": ` + "`" + `{{ .Encoded }}` + "`" + `,
+{{- end }}
+		},
+{{- end }}
+{{- end }}
 `
+
+	shardTemplate = `{{ define "shard" -}}
+// Code generated by "esc{{with .Invocation}} {{.}}{{end}}"; DO NOT EDIT.
+
+package {{.PackageName}}
+
+// This file is a shard of {{.ShardOf}}.
+
+func init() {
+{{- range .Files }}
+	_esc{{$.Ident}}Data["{{ .Name }}"] = &_esc{{$.Ident}}File{
+		{{- template "entry" . }}
+	}
+{{- end }}
+}
+{{ end }}`
 )
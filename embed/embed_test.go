@@ -5,10 +5,24 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
 	"io"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func Test_canonicFileName(t *testing.T) {
@@ -22,6 +36,9 @@ func Test_canonicFileName(t *testing.T) {
 		{"simple with prefix", "/ololo", "trololo", "/ololo"},
 		{"simple start with prefix", "trololo/ololo", "trololo", "/ololo"},
 		{"prefix in the middle", "start/trololo/ololo", "trololo", "/start/trololo/ololo"},
+		{"prefix with trailing slash", "trololo/ololo", "trololo/", "/ololo"},
+		{"prefix of a longer name", "trololo2/ololo", "trololo", "/trololo2/ololo"},
+		{"prefix is the name", "trololo", "trololo", "/"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -33,6 +50,391 @@ func Test_canonicFileName(t *testing.T) {
 	}
 }
 
+func Test_assetIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/css/main.css", "AssetCSSMainCSS"},
+		{"/assets/js/jquery.min.js", "AssetAssetsJSJqueryMinJS"},
+		{"/images/pic01.jpg", "AssetImagesPic01JPG"},
+		{"/1.txt", "Asset1Txt"},
+		{"/a b/c-d_e", "AssetABCDE"},
+	}
+	for _, tt := range tests {
+		if got := assetIdent(tt.name); got != tt.want {
+			t.Errorf("assetIdent(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	consts := assetConsts([]*_escFile{{Name: "/a-b"}, {Name: "/a_b"}, {Name: "/a.b"}})
+	var idents []string
+	for _, c := range consts {
+		idents = append(idents, c.Ident)
+	}
+	if want := []string{"AssetAB", "AssetAB_2", "AssetAB_3"}; !reflect.DeepEqual(idents, want) {
+		t.Errorf("assetConsts() = %v, want %v", idents, want)
+	}
+}
+
+func Test_minify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"a.json", "{\n  \"a\": [1, 2],\n  \"b\": \"x y\"\n}\n", `{"a":[1,2],"b":"x y"}`},
+		{"a.css", "/* c */\nbody,\nh1 > p {\n\tcolor: red;\n\tfont: 1em  \"A  B\";\n}\n", `body,h1>p{color:red;font:1em "A  B"}`},
+		{"a.css", "/*! license */ a :hover { margin: calc(1px + 2px) }", `/*! license */ a :hover{margin:calc(1px + 2px)}`},
+		{"a.css", "@media screen and (max-width: 10px) { a { b: c } }", `@media screen and (max-width:10px){a{b:c}}`},
+		{"a.HTML", "<p  class=\"a  b\">\n  x <!-- c -->\n  y</p>\n<pre>\n 1  2\n</PRE>", "<p class=\"a  b\"> x y</p> <pre>\n 1  2\n</PRE>"},
+		{"a.html", "<script>// x\nf()</script>\n<!--[if IE]><p>ie</p><![endif]-->", "<script>// x\nf()</script> <!--[if IE]><p>ie</p><![endif]-->"},
+		{"a.svg", "<svg>\n  <!-- c -->\n  <path d=\"M0  0\"/>\n</svg>\n", "<svg> <path d=\"M0  0\"/> </svg> "},
+		{"a.js", "var a = 1;  // x\n", "var a = 1;  // x\n"},
+	}
+	for _, tt := range tests {
+		got, err := minify(tt.name, []byte(tt.in))
+		if err != nil {
+			t.Errorf("minify(%q, %q) error = %v", tt.name, tt.in, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("minify(%q, %q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+	if _, err := minify("a.json", []byte("{")); err == nil {
+		t.Errorf("minify of invalid JSON must err")
+	}
+}
+
+func TestRunMinify(t *testing.T) {
+	conf := &Config{
+		Package: "main",
+		Files:   []string{"../testdata/assets/css", "../testdata/assets/txt"},
+		Minify:  `\.(css|txt)$`,
+	}
+	g, err := newGenerator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, base := range conf.Files {
+		if err := g.walk(base); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range g.files {
+		orig, err := ioutil.ReadFile(f.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(f.Name, ".css") {
+			if len(f.Data) >= len(orig) || bytes.Contains(f.Data, []byte("\n")) {
+				t.Errorf("%s was not minified", f.Name)
+			}
+		} else if !bytes.Equal(f.Data, orig) {
+			t.Errorf("%s was changed", f.Name)
+		}
+	}
+
+	err = Run(&Config{Package: "main", Minify: "**"}, ioutil.Discard)
+	var e *PatternError
+	if !errors.As(err, &e) || e.Option != "Minify" {
+		t.Errorf("Run() error = %v, want *PatternError for Minify", err)
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"a.txt":         "a",
+		"b.tmpl":        "b",
+		"c.bak":         "c",
+		"sub/d.tmpl":    "d",
+		"sub/e.txt":     "e",
+		"sub/f.tmpl":    "f",
+		"other/g.json":  "{ }",
+		"other/h.shout": "h",
+	})
+	prefix := filepath.ToSlash(dir)
+	conf := &Config{
+		Package: "main",
+		Prefix:  prefix,
+		Files:   []string{dir},
+		Transforms: []Transform{
+			{Pattern: `\.tmpl$`, Func: func(name string, data []byte) (string, []byte, error) {
+				return strings.TrimSuffix(name, ".tmpl") + ".html", append([]byte("<p>"), data...), nil
+			}},
+			{Pattern: `\.bak$`, Func: func(name string, data []byte) (string, []byte, error) {
+				return "", nil, nil
+			}},
+			{Pattern: `f\.tmpl$`, Func: func(name string, data []byte) (string, []byte, error) {
+				return "/other/" + path.Base(name), data, nil
+			}},
+			{Pattern: `\.shout$`, Func: func(name string, data []byte) (string, []byte, error) {
+				return name + ".json", []byte(`{"h": true}`), nil
+			}},
+		},
+		Minify: `\.(shout|json)$`,
+	}
+	g, err := newGenerator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.walk(dir); err != nil {
+		t.Fatal(err)
+	}
+	files, dirs := g.sorted()
+	got := make(map[string]string)
+	for _, f := range files {
+		got[f.Name] = string(f.Data)
+	}
+	want := map[string]string{
+		"/a.txt":              "a",
+		"/b.html":             "<p>b",
+		"/other/f.html":       "<p>f",
+		"/other/g.json":       "{}",
+		"/other/h.shout.json": `{"h":true}`,
+		"/sub/d.html":         "<p>d",
+		"/sub/e.txt":          "e",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	listings := make(map[string][]string)
+	for _, d := range dirs {
+		listings[d.Name] = d.ChildFileNames
+	}
+	wantListings := map[string][]string{
+		"/":      {"/a.txt", "/b.html", "/other", "/sub"},
+		"/other": {"/other/f.html", "/other/g.json", "/other/h.shout.json"},
+		"/sub":   {"/sub/d.html", "/sub/e.txt"},
+	}
+	if !reflect.DeepEqual(listings, wantListings) {
+		t.Errorf("listings = %v, want %v", listings, wantListings)
+	}
+
+	conf.Transforms = []Transform{{Pattern: `b\.tmpl$`, Func: func(name string, data []byte) (string, []byte, error) {
+		return "/a.txt", data, nil
+	}}}
+	err = Run(conf, ioutil.Discard)
+	var dup *DuplicateNameError
+	if !errors.As(err, &dup) || dup.Name != "/a.txt" {
+		t.Errorf("Run() error = %v, want *DuplicateNameError for /a.txt", err)
+	}
+
+	fail := errors.New("fail")
+	conf.Transforms = []Transform{{Pattern: `e\.txt$`, Func: func(name string, data []byte) (string, []byte, error) {
+		return "", nil, fail
+	}}}
+	err = Run(conf, ioutil.Discard)
+	var fe *FileError
+	if !errors.As(err, &fe) || fe.Path != filepath.Join(dir, "sub", "e.txt") || !errors.Is(err, fail) {
+		t.Errorf("Run() error = %v, want *FileError for sub/e.txt", err)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	cache, err := ioutil.TempDir("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	helper := func(mode string) []string {
+		return []string{os.Args[0], "-test.run=TestHelperProcess", "--", mode}
+	}
+	conf := &Config{
+		Package:  "main",
+		Prefix:   "../testdata/assets",
+		Files:    []string{"../testdata/assets/txt"},
+		Commands: []Command{{Pattern: `\.txt$`, Rename: ".TXT", Args: helper("upper")}},
+		CacheDir: cache,
+	}
+	run := func() *_escFile {
+		g, err := newGenerator(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.walk("../testdata/assets/txt"); err != nil {
+			t.Fatal(err)
+		}
+		files, _ := g.sorted()
+		if len(files) != 1 {
+			t.Fatalf("got %d files, want 1", len(files))
+		}
+		return files[0]
+	}
+	f := run()
+	orig, _ := ioutil.ReadFile("../testdata/assets/txt/1.txt")
+	if f.Name != "/txt/1.TXT" || string(f.Data) != strings.ToUpper(string(orig)) {
+		t.Errorf("got %s %q", f.Name, f.Data)
+	}
+
+	entries, err := ioutil.ReadDir(cache)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("cache holds %v, %v; want one entry", entries, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(cache, entries[0].Name()), []byte("cached"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if f := run(); string(f.Data) != "cached" {
+		t.Errorf("got %q, want cached output", f.Data)
+	}
+
+	conf.Commands[0].Args = helper("fail")
+	err = Run(conf, ioutil.Discard)
+	var fe *FileError
+	var ce *CommandError
+	if !errors.As(err, &fe) || fe.Path != "../testdata/assets/txt/1.txt" || !errors.As(err, &ce) || ce.Stderr != "failed on /txt/1.TXT" {
+		t.Errorf("Run() error = %v, want *CommandError in *FileError", err)
+	}
+}
+
+// TestHelperProcess is run by TestCommands as an external command.
+func TestHelperProcess(t *testing.T) {
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		return
+	}
+	switch args[1] {
+	case "upper":
+		b, _ := ioutil.ReadAll(os.Stdin)
+		os.Stdout.Write(bytes.ToUpper(b))
+	case "fail":
+		fmt.Fprintf(os.Stderr, "failed on %s\n", os.Getenv("ESC_NAME"))
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestMounts(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"LICENSE":                "LICENSE",
+		"web/dist/app.js":        "web/dist/app.js",
+		"web/dist/css/app.css":   "web/dist/css/app.css",
+		"web/public/favicon.ico": "web/public/favicon.ico",
+		"docs/index.html":        "docs/index.html",
+	})
+	join := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+	conf := &Config{
+		Package: "main",
+		Prefix:  filepath.ToSlash(join("docs")),
+		Files:   []string{join("docs")},
+		Mounts: []Mount{
+			{Source: join("web/dist"), Target: "/static"},
+			{Source: join("web/public"), Target: "static/"},
+			{Source: join("LICENSE"), Target: "/about/license.txt"},
+		},
+	}
+	g, err := newGenerator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.collect(); err != nil {
+		t.Fatal(err)
+	}
+	files, dirs := g.sorted()
+	got := make(map[string]string)
+	for _, f := range files {
+		got[f.Name] = string(f.Data)
+	}
+	want := map[string]string{
+		"/index.html":         "docs/index.html",
+		"/static/app.js":      "web/dist/app.js",
+		"/static/css/app.css": "web/dist/css/app.css",
+		"/static/favicon.ico": "web/public/favicon.ico",
+		"/about/license.txt":  "LICENSE",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	listings := make(map[string][]string)
+	locals := make(map[string]string)
+	for _, d := range dirs {
+		listings[d.Name] = d.ChildFileNames
+		locals[d.Name] = d.Local
+	}
+	wantListings := map[string][]string{
+		"/":           {"/about", "/index.html", "/static"},
+		"/about":      {"/about/license.txt"},
+		"/static":     {"/static/app.js", "/static/css", "/static/favicon.ico"},
+		"/static/css": {"/static/css/app.css"},
+	}
+	if !reflect.DeepEqual(listings, wantListings) {
+		t.Errorf("listings = %v, want %v", listings, wantListings)
+	}
+	if locals["/about"] != "" || locals["/static"] != filepath.ToSlash(join("web/dist")) {
+		t.Errorf("got locals %v", locals)
+	}
+
+	for _, mounts := range [][]Mount{
+		{{Source: join("web/dist"), Target: "/"}, {Source: join("LICENSE"), Target: "/app.js"}},
+		{{Source: join("LICENSE"), Target: "/css"}, {Source: join("web/dist"), Target: "/"}},
+		{{Source: join("LICENSE"), Target: "/index.html/x"}},
+	} {
+		conf.Mounts = mounts
+		err := Run(conf, ioutil.Discard)
+		var e *DuplicateNameError
+		if !errors.As(err, &e) {
+			t.Errorf("Run() with mounts %v error = %v, want *DuplicateNameError", mounts, err)
+		}
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"frontend/build/index.html": "frontend/build/index.html",
+		"docs/site/index.html":      "docs/site/index.html",
+		"docs/site/guide.html":      "docs/site/guide.html",
+	})
+	frontend := filepath.Join(dir, "frontend", "build")
+	docs := filepath.Join(dir, "docs", "site")
+	conf := &Config{
+		Package: "main",
+		Prefix:  filepath.ToSlash(dir),
+		Files:   []string{frontend, docs},
+		Prefixes: map[string]string{
+			frontend: filepath.ToSlash(frontend),
+		},
+	}
+	g, err := newGenerator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.collect(); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range g.files {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"/docs/site/guide.html", "/docs/site/index.html", "/index.html"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	conf.Prefixes[docs+string(filepath.Separator)] = filepath.ToSlash(docs) + "/"
+	err = Run(conf, ioutil.Discard)
+	var e *DuplicateNameError
+	if !errors.As(err, &e) {
+		t.Fatalf("Run() error = %v, want *DuplicateNameError", err)
+	}
+	want := DuplicateNameError{
+		Name:        "/index.html",
+		Path:        filepath.ToSlash(filepath.Join(docs, "index.html")),
+		Other:       filepath.ToSlash(filepath.Join(frontend, "index.html")),
+		Prefix:      filepath.ToSlash(docs) + "/",
+		OtherPrefix: filepath.ToSlash(frontend),
+	}
+	if *e != want {
+		t.Errorf("got %+v, want %+v", *e, want)
+	}
+	if msg := e.Error(); !strings.Contains(msg, "after removing prefix") {
+		t.Errorf("error %q does not name the prefixes", msg)
+	}
+}
+
 func TestRun(t *testing.T) {
 	o := ioutil.Discard
 
@@ -46,6 +448,7 @@ func TestRun(t *testing.T) {
 		{"wrong modtime - must err", &Config{ModTime: "xxx"}, o, true},
 		{"wrong ignore regexp - must err", &Config{Ignore: "**/xxx/**"}, o, true},
 		{"wrong include regexp - must err", &Config{Include: "**/xxx/**"}, o, true},
+		{"wrong bundle - must err", &Config{Package: "main", Bundle: "a-b"}, o, true},
 		{"testdata", &Config{Package: "main", Files: []string{"../testdata"}}, o, false},
 		{"testdata with ignore", &Config{
 			Package: "main",
@@ -67,6 +470,271 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func Test_isCompressed(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte("hello"))
+	gw.Close()
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"gzip", gz.Bytes(), true},
+		{"zip", []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00"), true},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), true},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), true},
+		{"woff2", []byte("wOF2\x00\x01\x00\x00"), true},
+		{"text", []byte("body { color: red }"), false},
+		{"html", []byte("<!doctype html><title>x</title>"), false},
+		{"empty", nil, false},
+	}
+	for _, tt := range tests {
+		if got := isCompressed(tt.data); got != tt.want {
+			t.Errorf("isCompressed(%s) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunCompressed(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		var log bytes.Buffer
+		conf := &Config{
+			Package:          "main",
+			Files:            []string{"../testdata/images/overlay.png", "../testdata/assets/txt"},
+			ForceCompression: force,
+			Log:              &log,
+		}
+		g, err := newGenerator(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.collect(); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range g.files {
+			wantStored := !force && path.Ext(f.Name) == ".png"
+			if f.stored != wantStored {
+				t.Errorf("force=%t: %s stored = %t, want %t", force, f.Name, f.stored, wantStored)
+			}
+			if wantStored && len(f.gzipped) <= len(f.Data) {
+				t.Errorf("force=%t: %s was compressed to %d of %d bytes", force, f.Name, len(f.gzipped), len(f.Data))
+			}
+		}
+		g.logSummary()
+		if got := log.String(); force && got != "" || !force && !strings.HasPrefix(got, "esc: stored 1 already compressed files (") {
+			t.Errorf("force=%t: logged %q", force, got)
+		}
+	}
+}
+
+func TestSizes(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"a.txt":           strings.Repeat("x", 100),
+		"img/b.txt":       strings.Repeat("x", 300),
+		"img/c.txt":       strings.Repeat("x", 200),
+		"img/icons/d.txt": strings.Repeat("x", 50),
+	})
+	tests := []struct {
+		name string
+		conf Config
+		want *SizeError
+	}{
+		{"within", Config{MaxFileSize: 300, MaxTotalSize: 650, MaxCompressedSize: 1000}, nil},
+		{"file", Config{MaxFileSize: 150}, &SizeError{Limit: "MaxFileSize", Max: 150, Size: 300,
+			Files: []FileSize{{"/img/b.txt", 300}, {"/img/c.txt", 200}}}},
+		{"total", Config{MaxTotalSize: 600}, &SizeError{Limit: "MaxTotalSize", Max: 600, Size: 650,
+			Files: []FileSize{{"/img/b.txt", 300}, {"/img/c.txt", 200}, {"/a.txt", 100}, {"/img/icons/d.txt", 50}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := tt.conf
+			conf.Package = "main"
+			conf.Prefix = filepath.ToSlash(dir)
+			conf.Files = []string{dir}
+			err := Run(&conf, ioutil.Discard)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Run() error = %v", err)
+				}
+				return
+			}
+			var e *SizeError
+			if !errors.As(err, &e) {
+				t.Fatalf("Run() error = %v, want *SizeError", err)
+			}
+			if !reflect.DeepEqual(e, tt.want) {
+				t.Errorf("got %+v, want %+v", e, tt.want)
+			}
+			if msg := e.Error(); !strings.Contains(msg, "/img/b.txt (300 bytes)") {
+				t.Errorf("error %q does not list the largest file", msg)
+			}
+		})
+	}
+
+	var report bytes.Buffer
+	conf := &Config{Package: "main", Prefix: filepath.ToSlash(dir), Files: []string{dir}, MaxCompressedSize: 1, Report: &report}
+	var e *SizeError
+	if err := Run(conf, ioutil.Discard); !errors.As(err, &e) || e.Limit != "MaxCompressedSize" || len(e.Files) != 4 {
+		t.Errorf("Run() error = %v, want *SizeError for MaxCompressedSize", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(report.String()), "\n") {
+		f := strings.Fields(line)
+		lines = append(lines, strings.Join(f[:3], " "))
+	}
+	want := []string{"DIRECTORY FILES SIZE", "/ 4 650", "/img 3 550", "/img/icons 1 50"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("report = %q, want %q", lines, want)
+	}
+
+	// Generated code holds the compressed data in base64, an archive does
+	// not.
+	for _, archive := range []string{"", filepath.Join(dir, "static.esc")} {
+		conf := &Config{Package: "main", Files: []string{dir}, Archive: archive, MaxCompressedSize: 1}
+		var e *SizeError
+		if err := Run(conf, ioutil.Discard); !errors.As(err, &e) {
+			t.Fatalf("Run() error = %v, want *SizeError", err)
+		}
+		g, err := newGenerator(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.conf.MaxCompressedSize = 0
+		if err := g.collect(); err != nil {
+			t.Fatal(err)
+		}
+		var want int64
+		for _, f := range g.files {
+			if archive != "" {
+				want += int64(len(f.gzipped))
+			} else {
+				want += int64(len(f.Compressed))
+			}
+		}
+		if e.Size != want {
+			t.Errorf("archive=%q: MaxCompressedSize counted %d bytes, want %d", archive, e.Size, want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	files := []string{"../testdata/assets/txt", "../testdata/images/overlay.png"}
+	var buf bytes.Buffer
+	if err := Run(&Config{Package: "main", Files: files, Stats: &buf, StatsJSON: true}, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	var stats []stat
+	if err := json.Unmarshal(buf.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]stat)
+	var names []string
+	for _, s := range stats {
+		byName[s.Name] = s
+		names = append(names, s.Name)
+	}
+	want := []string{"/", "/testdata", "/testdata/assets", "/testdata/assets/txt", "/testdata/assets/txt/1.txt", "/testdata/images", "/testdata/images/overlay.png"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %q, want %q", names, want)
+	}
+	txt, png, root := byName["/testdata/assets/txt/1.txt"], byName["/testdata/images/overlay.png"], byName["/"]
+	if txt.Dir || txt.Stored || txt.Size != 9 || txt.Compressed == 0 || txt.Ratio != float64(txt.Compressed)/9 {
+		t.Errorf("got %+v for 1.txt", txt)
+	}
+	if !png.Stored || png.Compressed <= png.Size {
+		t.Errorf("got %+v for overlay.png", png)
+	}
+	if !root.Dir || root.Size != txt.Size+png.Size || root.Compressed != txt.Compressed+png.Compressed || root.Time != txt.Time+png.Time {
+		t.Errorf("got %+v for /, want the totals of %+v and %+v", root, txt, png)
+	}
+
+	buf.Reset()
+	if err := Run(&Config{Package: "main", Files: files, Stats: &buf}, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want)+1 || !strings.HasPrefix(lines[0], "NAME") {
+		t.Fatalf("table = %q", lines)
+	}
+	for i, line := range lines[1:] {
+		name := strings.Fields(line)[0]
+		if name != want[i] && name != want[i]+"/" {
+			t.Errorf("row %d is for %s, want %s", i, name, want[i])
+		}
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], " (stored)") {
+		t.Errorf("row %q does not note that overlay.png is stored", lines[len(lines)-1])
+	}
+}
+
+func TestDepFile(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"web/index.html":   "web/index.html",
+		"web/my page.html": "web/my page.html",
+		"web/css/site.css": "web/css/site.css",
+		"web/notes.txt":    "web/notes.txt",
+	})
+	join := func(name string) string { return filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(name))) }
+	conf := &Config{
+		Package:    "main",
+		OutputFile: filepath.Join(dir, "static.go"),
+		Prefix:     join("web"),
+		Files:      []string{filepath.Join(dir, "web")},
+		Ignore:     `\.txt$`,
+		DepFile:    filepath.Join(dir, "static.d"),
+	}
+	run := func() []byte {
+		out, err := os.Create(conf.OutputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer out.Close()
+		if err := Run(conf, out); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(conf.DepFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	esc := func(name string) string { return strings.Replace(join(name), " ", `\ `, -1) }
+	want := esc("static.go") + ": \\\n  " + strings.Join([]string{
+		esc("web/css/site.css"),
+		esc("web/index.html"),
+		esc("web/my page.html"),
+		esc("web"),
+		esc("web/css"),
+	}, " \\\n  ") + "\n"
+	for _, f := range []string{"web/css/site.css", "web/index.html", "web/my page.html"} {
+		want += "\n" + esc(f) + ":\n"
+	}
+	if got := string(run()); got != want {
+		t.Errorf("depfile = %q, want %q", got, want)
+	}
+
+	conf.DepFileJSON = true
+	var got depFile
+	if err := json.Unmarshal(run(), &got); err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := depFile{
+		Outputs: []string{join("static.go")},
+		Files:   []string{join("web/css/site.css"), join("web/index.html"), join("web/my page.html")},
+		Dirs:    []string{join("web"), join("web/css")},
+	}
+	if !reflect.DeepEqual(got, wantJSON) {
+		t.Errorf("depfile = %+v, want %+v", got, wantJSON)
+	}
+
+	conf.OutputFile = ""
+	if err := Run(conf, ioutil.Discard); err == nil {
+		t.Errorf("Run() without an output file succeeded")
+	}
+}
+
 func TestDirOrder(t *testing.T) {
 	var buf bytes.Buffer
 	config := &Config{
@@ -85,6 +753,667 @@ func TestDirOrder(t *testing.T) {
 	}
 }
 
+func TestRunErrors(t *testing.T) {
+	o := ioutil.Discard
+
+	t.Run("config", func(t *testing.T) {
+		files := []string{"../testdata/assets/txt"}
+		for _, tt := range []struct {
+			option string
+			conf   *Config
+		}{
+			{"Bundle", &Config{Package: "main", Bundle: "a-b"}},
+			{"ModTime", &Config{Package: "main", ModTime: "xxx"}},
+			{"Commands", &Config{Package: "main", Commands: []Command{{Pattern: "x"}}}},
+			{"ShardSize", &Config{Package: "main", Files: []string{"../testdata/assets/css"}, ShardSize: 1}},
+			{"DepFile", &Config{Package: "main", Files: files, DepFile: filepath.Join(os.TempDir(), "esc-missing.d")}},
+		} {
+			err := Run(tt.conf, o)
+			var e *ConfigError
+			if !errors.As(err, &e) || e.Option != tt.option {
+				t.Errorf("Run() error = %v, want *ConfigError for %s", err, tt.option)
+			}
+		}
+		os.Setenv("SOURCE_DATE_EPOCH", "xxx")
+		defer os.Unsetenv("SOURCE_DATE_EPOCH")
+		err := Run(&Config{Package: "main", Reproducible: true}, o)
+		var e *ConfigError
+		if !errors.As(err, &e) || e.Option != "SOURCE_DATE_EPOCH" {
+			t.Errorf("Run() error = %v, want *ConfigError for SOURCE_DATE_EPOCH", err)
+		}
+	})
+	t.Run("pattern", func(t *testing.T) {
+		err := Run(&Config{Package: "main", Include: "**/xxx/**"}, o)
+		var e *PatternError
+		if !errors.As(err, &e) {
+			t.Fatalf("Run() error = %v, want *PatternError", err)
+		}
+		if e.Option != "Include" || e.Pattern != "**/xxx/**" {
+			t.Errorf("got %+v", e)
+		}
+	})
+	t.Run("file", func(t *testing.T) {
+		err := Run(&Config{Package: "main", Files: []string{"../testdata/missing"}}, o)
+		var e *FileError
+		if !errors.As(err, &e) {
+			t.Fatalf("Run() error = %v, want *FileError", err)
+		}
+		if e.Path != "../testdata/missing" || !os.IsNotExist(e.Err) {
+			t.Errorf("got %+v", e)
+		}
+	})
+	t.Run("duplicate", func(t *testing.T) {
+		err := Run(&Config{
+			Package: "main",
+			Files:   []string{"../testdata/empty/1", "../testdata/assets/txt/../../empty/1"},
+		}, o)
+		var e *DuplicateNameError
+		if !errors.As(err, &e) {
+			t.Fatalf("Run() error = %v, want *DuplicateNameError", err)
+		}
+		want := DuplicateNameError{
+			Name:  "/testdata/empty/1",
+			Path:  "../testdata/assets/txt/../../empty/1",
+			Other: "../testdata/empty/1",
+		}
+		if *e != want {
+			t.Errorf("got %+v, want %+v", *e, want)
+		}
+	})
+	t.Run("format", func(t *testing.T) {
+		err := Run(&Config{OutputFile: "out.go"}, o)
+		var e *FormatError
+		if !errors.As(err, &e) {
+			t.Fatalf("Run() error = %v, want *FormatError", err)
+		}
+		if e.Path != "out.go" {
+			t.Errorf("got %+v", e)
+		}
+	})
+}
+
+func TestShards(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "static.go")
+	conf := &Config{
+		OutputFile: out,
+		Package:    "main",
+		Files:      []string{"../testdata/assets/css"},
+		ShardSize:  1,
+	}
+	// A stale shard from an earlier run with more files must be removed, but
+	// not another esc output that happens to be named like a shard.
+	stale := filepath.Join(dir, "static_3.go")
+	other := filepath.Join(dir, "static_4.go")
+	for name, content := range map[string]string{
+		stale: "// Code generated by \"esc\"; DO NOT EDIT.\n\npackage main\n\n// This file is a shard of static.go.\n",
+		other: "// Code generated by \"esc -o static_4.go\"; DO NOT EDIT.\n\npackage main\n",
+	} {
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := Run(conf, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "compressed:") {
+		t.Errorf("main file contains file data")
+	}
+	for _, name := range []string{"static_1.go", "static_2.go"} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(b, []byte("func init() {")) {
+			t.Errorf("%s does not register its files", name)
+		}
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale shard was not removed: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("%s was removed: %v", other, err)
+	}
+
+	conf.OutputFile = ""
+	if err := Run(conf, &buf); err == nil {
+		t.Errorf("sharding without an output file must err")
+	}
+}
+
+func TestPrecompressed(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"app.js":          "app.js",
+		"app.js.gz":       "app.js.gz",
+		"app.js.br":       "app.js.br",
+		"notes.txt.gz":    "notes.txt.gz",
+		"css/site.css.br": "css/site.css.br",
+	})
+	conf := &Config{
+		Package:       "main",
+		Prefix:        filepath.ToSlash(dir),
+		Files:         []string{dir},
+		Precompressed: true,
+	}
+	g, err := newGenerator(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.collect(); err != nil {
+		t.Fatal(err)
+	}
+	files, dirs := g.sorted()
+	got := make(map[string][]string)
+	for _, f := range files {
+		got[f.Name] = []string{}
+		for _, e := range f.Encodings {
+			got[f.Name] = append(got[f.Name], e.Name+":"+string(e.Data))
+		}
+	}
+	want := map[string][]string{
+		"/app.js":          {"br:app.js.br", "gzip:app.js.gz"},
+		"/css/site.css.br": {},
+		"/notes.txt.gz":    {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if want := []string{"/app.js", "/css", "/notes.txt.gz"}; !reflect.DeepEqual(dirs[0].ChildFileNames, want) {
+		t.Errorf("listing of %s = %v, want %v", dirs[0].Name, dirs[0].ChildFileNames, want)
+	}
+
+	var buf bytes.Buffer
+	if err := writeArchive(&buf, "", files, dirs); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	trailer := b[len(b)-trailerLen:]
+	off := binary.LittleEndian.Uint64(trailer[0:8])
+	n := binary.LittleEndian.Uint64(trailer[8:16])
+	var index archiveIndex
+	if err := json.Unmarshal(b[off:off+n], &index); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range index.Entries {
+		if e.Name != "/app.js" {
+			continue
+		}
+		for enc, span := range e.Encodings {
+			data := string(b[span[0] : span[0]+span[1]])
+			if want := map[string]string{"br": "app.js.br", "gzip": "app.js.gz"}[enc]; data != want {
+				t.Errorf("archived %s encoding = %q, want %q", enc, data, want)
+			}
+		}
+		if len(e.Encodings) != 2 {
+			t.Errorf("archived encodings = %v, want br and gzip", e.Encodings)
+		}
+	}
+}
+
+func TestPrecompressedSiblings(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("var a = 1;"))
+	w.Close()
+	dir := writeTree(t, map[string]string{
+		"app.js":       "var a = 1;",
+		"app.js.gz":    gz.String(),
+		"notes.txt.gz": gz.String(),
+	})
+
+	// Only notes.txt.gz is a file of its own, stored as it is.
+	var log bytes.Buffer
+	g, err := newGenerator(&Config{Files: []string{dir}, Precompressed: true, Log: &log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.collect(); err != nil {
+		t.Fatal(err)
+	}
+	g.logSummary()
+	if got := log.String(); !strings.HasPrefix(got, "esc: stored 1 already compressed files (") {
+		t.Errorf("summary = %q, want 1 stored file", got)
+	}
+	for _, f := range g.files {
+		if f.gzipped == nil {
+			t.Errorf("%s was not compressed", f.Name)
+		}
+	}
+
+	// app.js.gz would no longer match a transformed app.js.
+	g, err = newGenerator(&Config{
+		Files:         []string{dir},
+		Precompressed: true,
+		Transforms: []Transform{{Pattern: `\.js$`, Func: func(name string, data []byte) (string, []byte, error) {
+			return name, bytes.TrimSuffix(data, []byte(";")), nil
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fe *FileError
+	if err := g.collect(); !errors.As(err, &fe) || !strings.HasSuffix(filepath.ToSlash(fe.Path), "/app.js") {
+		t.Errorf("collect() error = %v, want a *FileError for app.js", err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, "static.esc")
+	var buf bytes.Buffer
+	if err := Run(&Config{
+		Package: "main",
+		Files:   []string{"../testdata/empty", "../testdata/assets/txt"},
+		Archive: archive,
+	}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "compressed:") {
+		t.Errorf("output contains file data")
+	}
+	b, err := ioutil.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trailer := b[len(b)-24:]
+	if string(trailer[16:]) != archiveMagic {
+		t.Fatalf("archive does not end with %q", archiveMagic)
+	}
+	off := binary.LittleEndian.Uint64(trailer[0:8])
+	n := binary.LittleEndian.Uint64(trailer[8:16])
+	var index archiveIndex
+	if err := json.Unmarshal(b[off:off+n], &index); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range index.Entries {
+		if e.Name != "/testdata/assets/txt/1.txt" {
+			continue
+		}
+		gr, err := gzip.NewReader(bytes.NewReader(b[e.Offset : e.Offset+e.Length]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(gr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := ioutil.ReadFile("../testdata/assets/txt/1.txt")
+		if !bytes.Equal(data, want) {
+			t.Errorf("got %q, want %q", data, want)
+		}
+		return
+	}
+	t.Errorf("archive index %+v is missing 1.txt", index)
+}
+
+func TestAppend(t *testing.T) {
+	f, err := ioutil.TempFile("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	exe := []byte("\x7fELF stand-in for an executable")
+	f.Write(exe)
+	f.Close()
+
+	conf := &Config{Files: []string{"../testdata/assets/txt"}}
+	if err := Append(conf, f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	first, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(first, exe) || !bytes.HasSuffix(first, []byte(archiveMagic)) {
+		t.Fatalf("executable was not preserved or archive not appended")
+	}
+
+	// Appending again must replace the archive, not stack another one.
+	if err := Append(conf, f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	second, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("second Append changed the file from %d to %d bytes", len(first), len(second))
+	}
+
+	// Another bundle's archive is chained after the first one, and replacing
+	// the first bundle's archive keeps it.
+	admin := &Config{Files: []string{"../testdata/assets/css"}, Bundle: "Admin"}
+	if err := Append(admin, f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(conf, f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	r, err := os.Open(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	archives, err := findArchives(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundles []string
+	for _, a := range archives {
+		bundles = append(bundles, a.index.Bundle)
+	}
+	if want := []string{"Admin", ""}; !reflect.DeepEqual(bundles, want) {
+		t.Errorf("got archives for bundles %q, want %q", bundles, want)
+	}
+	if len(archives) > 0 && archives[0].start != int64(len(exe)) {
+		t.Errorf("executable was not preserved")
+	}
+
+	if err := Append(conf, filepath.Join(os.TempDir(), "esc-missing")); err == nil {
+		t.Errorf("appending to a missing executable must err")
+	}
+}
+
+func TestBuildPrivateHandler(t *testing.T) {
+	for _, conf := range []*Config{
+		{Private: true, Handler: true},
+		{Private: true, Handler: true, Bundle: "Admin"},
+		{Private: true, Handler: true, Precompressed: true, AssetConsts: true},
+		{Private: true, Handler: true, Archive: "static.esc"},
+	} {
+		buildOutputs(t, conf)
+	}
+}
+
+func TestBuildLocal(t *testing.T) {
+	for _, conf := range []*Config{
+		{LocalFallback: true},
+		{LocalFallback: true, Precompressed: true, Handler: true},
+		{Precompressed: true},
+		{Precompressed: true, Archive: "static.esc"},
+	} {
+		buildOutputs(t, conf)
+	}
+}
+
+func TestBuildBundles(t *testing.T) {
+	// Each bundle name is the start of an unexported identifier of the
+	// default bundle, or of a private function of another bundle.
+	buildOutputs(t,
+		&Config{Handler: true},
+		&Config{Handler: true, Bundle: "HTTP"},
+		&Config{Handler: true, Bundle: "Local", Private: true},
+		&Config{Handler: true, Bundle: "Local_", Private: true},
+	)
+}
+
+// buildOutputs generates the outputs of confs into one package of a new
+// module and vets it with the go command. Files default to
+// ../testdata/assets/txt.
+func buildOutputs(t *testing.T, confs ...*Config) {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	dir := writeTree(t, map[string]string{
+		"go.mod":  "module esctest\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+	for i, conf := range confs {
+		conf.Package = "main"
+		conf.OutputFile = filepath.Join(dir, fmt.Sprintf("static%d.go", i))
+		if conf.Archive != "" {
+			conf.Archive = filepath.Join(dir, conf.Archive)
+		}
+		if len(conf.Files) == 0 {
+			conf.Files = []string{"../testdata/assets/txt"}
+		}
+		var buf bytes.Buffer
+		if err := Run(conf, &buf); err != nil {
+			t.Fatalf("Run(%+v) error = %v", conf, err)
+		}
+		if err := ioutil.WriteFile(conf.OutputFile, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goCmd, "vet", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go vet of the output for %+v failed: %v\n%s", confs, err, out)
+	}
+}
+
+func TestRunConcurrent(t *testing.T) {
+	const n = 8
+	outs := make([]bytes.Buffer, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = Run(&Config{
+				Package: "main",
+				Files:   []string{"../testdata/empty"},
+				ModTime: strconv.Itoa(i + 1),
+			}, &outs[i])
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		want := fmt.Sprintf("modtime: %d,", i+1)
+		if got := strings.Count(outs[i].String(), want); got != 2 {
+			t.Errorf("run %d: found %q %d times, want 2", i, want, got)
+		}
+	}
+
+	// A ModTime from an earlier Run must not leak into the next one.
+	var buf bytes.Buffer
+	if err := Run(&Config{Package: "main", Files: []string{"../testdata/empty"}}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), fmt.Sprintf("modtime: %d,", n)) {
+		t.Errorf("modtime leaked from a previous Run")
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	// run generates output from a fresh copy of the same tree in a new
+	// directory with distinct modification times.
+	run := func(mtime time.Time) (string, error) {
+		dir, err := filepath.EvalSymlinks(writeTree(t, map[string]string{
+			"assets/a.txt":     "a",
+			"assets/sub/b.txt": "b",
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"assets/a.txt", "assets/sub/b.txt"} {
+			if err := os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(wd)
+		assets := filepath.Join(dir, "assets")
+		var buf bytes.Buffer
+		err = Run(&Config{
+			Package:      "main",
+			Prefix:       assets,
+			Files:        []string{assets},
+			Invocation:   "-reproducible -prefix=" + assets + " " + assets,
+			Reproducible: true,
+		}, &buf)
+		return buf.String(), err
+	}
+
+	first, err := run(time.Unix(1000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := run(time.Unix(2000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("output differs between runs:\n%s\n%s", first, second)
+	}
+	for _, want := range []string{
+		`"esc -reproducible -prefix=assets assets"`,
+		"modtime: 0,",
+		"local:   \"assets/sub/b.txt\"",
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("output does not contain %s", want)
+		}
+	}
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1234")
+	got, err := run(time.Unix(1000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "modtime: 1234,") {
+		t.Errorf("SOURCE_DATE_EPOCH was not used as modtime")
+	}
+	os.Setenv("SOURCE_DATE_EPOCH", "xxx")
+	if _, err := run(time.Unix(1000, 0)); err == nil {
+		t.Errorf("invalid SOURCE_DATE_EPOCH must err")
+	}
+}
+
+func TestUnused(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"assets/css/a.css":     "a",
+		"assets/css/a.css.map": "map",
+		"assets/b.js":          "b",
+		"assets/d.txt":         "d",
+		"assets/e.png":         "e",
+		"main.go":              "package main\n\nvar a = \"/static/css/a.css?v=1\"\nvar d = AssetDTxt\n",
+		"web/page.html":        `<script src="{{.Root}}/b.js"></script><img src="e.png.bak">`,
+	})
+	assets := filepath.ToSlash(filepath.Join(dir, "assets"))
+	static := filepath.Join(dir, "static.go")
+	out, err := os.Create(static)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Run(&Config{
+		Package:     "main",
+		Prefix:      assets,
+		Files:       []string{assets},
+		AssetConsts: true,
+	}, out)
+	out.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unused, err := Unused([]string{static}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UnusedAsset{
+		{File: static, Name: "/css/a.css.map"},
+		{File: static, Name: "/e.png"},
+	}
+	if !reflect.DeepEqual(unused, want) {
+		t.Errorf("Unused() = %v, want %v", unused, want)
+	}
+}
+
+func TestUnusedBundle(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"assets/d.txt": "d",
+		"assets/e.txt": "e",
+		"other/f.txt":  "f",
+		"main.go":      "package main\n\nvar d = _escAdmin_AssetDTxt\n",
+	})
+	generate := func(name, files string, conf *Config) string {
+		t.Helper()
+		conf.Package = "main"
+		conf.Prefix = filepath.ToSlash(filepath.Join(dir, files))
+		conf.Files = []string{conf.Prefix}
+		fname := filepath.Join(dir, name)
+		out, err := os.Create(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer out.Close()
+		if err := Run(conf, out); err != nil {
+			t.Fatal(err)
+		}
+		return fname
+	}
+	static := generate("static.go", "assets", &Config{Bundle: "Admin", Private: true, AssetConsts: true})
+	// static_1.go is named like a shard of static.go, but is not one.
+	generate("static_1.go", "other", &Config{})
+
+	unused, err := Unused([]string{static}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UnusedAsset{{File: static, Name: "/e.txt"}}
+	if !reflect.DeepEqual(unused, want) {
+		t.Errorf("Unused() = %v, want %v", unused, want)
+	}
+}
+
+func TestUnusedArchive(t *testing.T) {
+	// The archive path is relative to the directory esc ran in, which is
+	// not the current one.
+	dir := writeTree(t, map[string]string{
+		"static.go": "package main\n\nconst _escArchiveDefault = \"static.esc\"\n",
+	})
+	static := filepath.Join(dir, "static.go")
+	files := []*_escFile{{Name: "/a.txt"}, {Name: "/b.txt"}}
+	var buf bytes.Buffer
+	if err := writeArchive(&buf, "", files, nil); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "static.esc")
+	if err := ioutil.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	unused, err := Unused([]string{static}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UnusedAsset{{File: static, Name: "/a.txt"}, {File: static, Name: "/b.txt"}}
+	if !reflect.DeepEqual(unused, want) {
+		t.Errorf("Unused() = %v, want %v", unused, want)
+	}
+
+	os.Remove(archive)
+	_, err = Unused([]string{static}, dir)
+	var e *FileError
+	if !errors.As(err, &e) || e.Path != archive {
+		t.Errorf("Unused() error = %v, want a *FileError for %s", err, archive)
+	}
+}
+
 func Test_escFile_fillCompressed(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -481,3 +1810,60 @@ JFP81+UPbz79xGurYxOjb6O6zsr9D99988e0iQr0XDXvWz+ZRcESLVarXRTOZ8vFY7BKVnEcBLM4WayX
 82ncttMiqr1P3/zh/wsAAP//uR4jxH7CAQA=
 `
 )
+
+// BenchmarkRun generates the example, and formats its output with
+// go/format.
+func BenchmarkRun(b *testing.B) {
+	conf := func() *Config {
+		return &Config{
+			Package:       "main",
+			Prefix:        "../testdata",
+			Files:         []string{"../testdata"},
+			Handler:       true,
+			LocalFallback: true,
+		}
+	}
+	var out bytes.Buffer
+	if err := Run(conf(), &out); err != nil {
+		b.Fatal(err)
+	}
+	b.Run("run", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := Run(conf(), ioutil.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("format", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(out.Len()))
+		for i := 0; i < b.N; i++ {
+			if _, err := format.Source(out.Bytes()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// writeTree writes files, mapping slash-separated paths to contents, below a
+// new temporary directory, which is removed when the test ends, and returns
+// the directory.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fname, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
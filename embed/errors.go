@@ -0,0 +1,161 @@
+package embed
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DuplicateNameError is returned by Run when two files are embedded under the
+// same name.
+type DuplicateNameError struct {
+	// Name is the embedded name both files map to.
+	Name string
+	// Path is the file that was rejected.
+	Path string
+	// Other is the file already embedded as Name.
+	Other string
+	// Prefix and OtherPrefix are the prefixes stripped from Path and Other.
+	Prefix      string
+	OtherPrefix string
+}
+
+func (e *DuplicateNameError) Error() string {
+	if e.Prefix != e.OtherPrefix {
+		return fmt.Sprintf("%s, %s: duplicate Name after removing prefix %q (already used by %s after removing prefix %q)", e.Name, e.Path, e.Prefix, e.Other, e.OtherPrefix)
+	}
+	return fmt.Sprintf("%s, %s: duplicate Name after prefix removal (already used by %s)", e.Name, e.Path, e.Other)
+}
+
+// FileError is returned by Run when a file or directory cannot be read.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error { return e.Err }
+
+// ConfigError is returned by Run when an option of Config, or an environment
+// variable it reads, is invalid or conflicts with another option.
+type ConfigError struct {
+	// Option is the Config field or environment variable at fault, for
+	// example "ModTime" or "SOURCE_DATE_EPOCH".
+	Option string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Option, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// PatternError is returned by Run when a Config regular expression does not
+// compile.
+type PatternError struct {
+	// Option is the Config field holding the pattern, for example "Ignore".
+	Option  string
+	Pattern string
+	Err     error
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("invalid %s pattern %q: %v", e.Option, e.Pattern, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PatternError) Unwrap() error { return e.Err }
+
+// TemplateError is returned by Run when the output template fails to execute.
+type TemplateError struct {
+	Err error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("executing template: %v", e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *TemplateError) Unwrap() error { return e.Err }
+
+// FormatError is returned by Run when the generated source cannot be
+// formatted.
+type FormatError struct {
+	// Path is the output file name the source was generated for.
+	Path string
+	Err  error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("formatting %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FormatError) Unwrap() error { return e.Err }
+
+// CommandError is returned by Run, wrapped in a *FileError, when a program
+// from Config.Commands fails.
+type CommandError struct {
+	Args []string
+	// Stderr is what the program wrote to its standard error.
+	Stderr string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s: %v", strings.Join(e.Args, " "), e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *CommandError) Unwrap() error { return e.Err }
+
+// SizeError is returned by Run when the embedded files exceed a size limit
+// of Config.
+type SizeError struct {
+	// Limit is the Config field that was exceeded, for example
+	// "MaxTotalSize", and Max is its value.
+	Limit string
+	Max   int64
+	// Size is the total size, or for MaxFileSize the size of the largest
+	// file.
+	Size int64
+	// Files are the largest offenders, largest first: the files over
+	// MaxFileSize, or else the largest files counting towards the total.
+	Files []FileSize
+}
+
+// FileSize is the size of an embedded file, before or after compression as
+// the limit it is reported for counts it.
+type FileSize struct {
+	Name string
+	Size int64
+}
+
+// maxOffenders is the number of files a SizeError lists.
+const maxOffenders = 10
+
+func (e *SizeError) Error() string {
+	var b strings.Builder
+	if e.Limit == "MaxFileSize" {
+		fmt.Fprintf(&b, "%d files exceed %s of %d bytes:", len(e.Files), e.Limit, e.Max)
+	} else {
+		fmt.Fprintf(&b, "files total %d bytes, exceeding %s of %d bytes; largest:", e.Size, e.Limit, e.Max)
+	}
+	for i, f := range e.Files {
+		if i == maxOffenders {
+			fmt.Fprintf(&b, "\n\tand %d more", len(e.Files)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n\t%s (%d bytes)", f.Name, f.Size)
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+package embed
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// minifiers are the minifiers used by Config.Minify, by file extension.
+var minifiers = map[string]func([]byte) ([]byte, error){
+	".css":  minifyCSS,
+	".htm":  minifyHTML,
+	".html": minifyHTML,
+	".json": minifyJSON,
+	".svg":  minifyHTML,
+}
+
+// minify minifies data with the minifier for the extension of name. Data
+// of other types is returned unchanged.
+func minify(name string, data []byte) ([]byte, error) {
+	if m, ok := minifiers[strings.ToLower(path.Ext(name))]; ok {
+		return m(data)
+	}
+	return data, nil
+}
+
+func minifyJSON(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// minifyCSS strips comments, except /*! ones, and whitespace that is not
+// needed to separate tokens.
+func minifyCSS(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	space := false
+	// emit writes b, preceded by a space if one is pending and needed.
+	emit := func(b []byte) {
+		if space && buf.Len() > 0 {
+			last := buf.Bytes()[buf.Len()-1]
+			if !strings.ContainsRune("{};,>:", rune(last)) && !strings.ContainsRune("{};,>", rune(b[0])) {
+				buf.WriteByte(' ')
+			}
+		}
+		space = false
+		if b[0] == '}' && buf.Len() > 0 && buf.Bytes()[buf.Len()-1] == ';' {
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.Write(b)
+	}
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"' || c == '\'':
+			end := quotedEnd(data, i)
+			emit(data[i:end])
+			i = end
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := len(data)
+			if j := bytes.Index(data[i+2:], []byte("*/")); j >= 0 {
+				end = i + 2 + j + 2
+			}
+			if i+2 < len(data) && data[i+2] == '!' {
+				emit(data[i:end])
+			} else {
+				space = true
+			}
+			i = end
+		case isSpace(c):
+			space = true
+			i++
+		default:
+			emit(data[i : i+1])
+			i++
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// quotedEnd returns the index after the string starting with the quote at
+// data[i], or len(data) if it is not terminated.
+func quotedEnd(data []byte, i int) int {
+	q := data[i]
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case q:
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// rawTags are the elements whose content minifyHTML leaves untouched.
+var rawTags = []string{"pre", "script", "style", "textarea"}
+
+// minifyHTML strips comments, except conditional ones, and collapses runs of
+// whitespace to a single space outside of quoted attributes and raw
+// elements. It also serves for SVG.
+func minifyHTML(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	space := func() {
+		if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != ' ' {
+			buf.WriteByte(' ')
+		}
+	}
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case bytes.HasPrefix(data[i:], []byte("<!--")) && !bytes.HasPrefix(data[i:], []byte("<!--[if")):
+			j := bytes.Index(data[i+4:], []byte("-->"))
+			if j < 0 {
+				i = len(data)
+			} else {
+				i += 4 + j + 3
+			}
+		case c == '<' && i+1 < len(data) && (isLetter(data[i+1]) || data[i+1] == '/' || data[i+1] == '!'):
+			// Copy the tag, collapsing whitespace outside of quotes.
+			start := i
+			buf.WriteByte('<')
+			for i++; i < len(data) && data[i] != '>'; {
+				switch d := data[i]; {
+				case d == '"' || d == '\'':
+					end := bytes.IndexByte(data[i+1:], d)
+					if end < 0 {
+						end = len(data) - i - 2
+					}
+					buf.Write(data[i : i+end+2])
+					i += end + 2
+				case isSpace(d):
+					space()
+					i++
+				default:
+					buf.WriteByte(d)
+					i++
+				}
+			}
+			if i < len(data) {
+				buf.WriteByte('>')
+				i++
+			}
+			if raw := rawTag(data[start+1:]); raw != "" {
+				end := indexFold(data[i:], "</"+raw)
+				if end < 0 {
+					end = len(data) - i
+				}
+				buf.Write(data[i : i+end])
+				i += end
+			}
+		case isSpace(c):
+			space()
+			i++
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// rawTag returns the raw element that tag, the text after a '<', opens, if
+// any.
+func rawTag(tag []byte) string {
+	for _, raw := range rawTags {
+		if len(tag) > len(raw) && strings.EqualFold(string(tag[:len(raw)]), raw) && !isLetter(tag[len(raw)]) {
+			return raw
+		}
+	}
+	return ""
+}
+
+// indexFold returns the index of the first instance of the ASCII string s in
+// data, ignoring case, or -1.
+func indexFold(data []byte, s string) int {
+	return bytes.Index(bytes.ToLower(data), []byte(s))
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
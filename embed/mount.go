@@ -0,0 +1,80 @@
+package embed
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Mount embeds a file or directory under a chosen name.
+type Mount struct {
+	// Source is the file or directory to embed.
+	Source string
+	// Target is the name Source is embedded as, for example "/static".
+	// Prefix does not apply to it.
+	Target string
+}
+
+// mount adds m.Source and, if it is a directory, everything below it, as
+// m.Target.
+func (g *generator) mount(m Mount) error {
+	target := path.Join("/", m.Target)
+	return g.walkNamed(m.Source, "", func(fname string) string {
+		rel, err := filepath.Rel(m.Source, fname)
+		if err != nil {
+			return target
+		}
+		return path.Join(target, filepath.ToSlash(rel))
+	})
+}
+
+// linkMounts lists every mount target in its parent directory, creating the
+// directories leading to it that are not embedded otherwise. These have no
+// local path.
+func (g *generator) linkMounts() error {
+	for _, m := range g.conf.Mounts {
+		name := path.Join("/", m.Target)
+		if _, ok := g.taken(name); !ok {
+			// Not embedded, for example because it is ignored.
+			continue
+		}
+		for name != "/" {
+			parent := path.Dir(name)
+			d, ok := g.dirIndex[parent]
+			if !ok {
+				if other, ok := g.prepared[parent]; ok {
+					return g.duplicate(parent, m.Source, "", other)
+				}
+				d = &_escDir{Name: parent, BaseName: path.Base(parent)}
+				g.dirs = append(g.dirs, d)
+				g.dirIndex[parent] = d
+			}
+			d.addChild(name)
+			name = parent
+		}
+	}
+	return nil
+}
+
+// taken reports whether a file or directory is embedded as name, and
+// returns its path.
+func (g *generator) taken(name string) (string, bool) {
+	if other, ok := g.prepared[name]; ok {
+		return other, true
+	}
+	if d, ok := g.dirIndex[name]; ok {
+		return d.Local, true
+	}
+	return "", false
+}
+
+// addChild adds name to the children of d, unless it is there already.
+func (d *_escDir) addChild(name string) {
+	i := sort.SearchStrings(d.ChildFileNames, name)
+	if i < len(d.ChildFileNames) && d.ChildFileNames[i] == name {
+		return
+	}
+	d.ChildFileNames = append(d.ChildFileNames, "")
+	copy(d.ChildFileNames[i+1:], d.ChildFileNames[i:])
+	d.ChildFileNames[i] = name
+}
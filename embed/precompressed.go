@@ -0,0 +1,90 @@
+package embed
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+// precompressed are the encodings of Config.Precompressed, in the order the
+// generated handler prefers them, with the suffixes of their files.
+var precompressed = []struct {
+	suffix   string
+	encoding string
+}{
+	{".br", "br"},
+	{".gz", "gzip"},
+}
+
+// _escEncoding is a precompressed encoding of a file.
+type _escEncoding struct {
+	// Name is the Content-Encoding, such as "br".
+	Name    string
+	Data    []byte
+	Encoded string
+}
+
+// attachEncodings embeds the files ending in a suffix of precompressed next
+// to another file as encodings of that file, instead of as files of their
+// own. It returns a *FileError if a transform changed a file that has
+// encodings, as they would no longer match it.
+func (g *generator) attachEncodings() error {
+	if !g.conf.Precompressed {
+		return nil
+	}
+	byName := make(map[string]*_escFile, len(g.files))
+	for _, f := range g.files {
+		byName[f.Name] = f
+	}
+	attached := make(map[*_escFile]bool)
+	for _, f := range g.files {
+		if attached[f] {
+			continue
+		}
+		for _, p := range precompressed {
+			e, ok := byName[f.Name+p.suffix]
+			if !ok || attached[e] {
+				continue
+			}
+			if f.transformed {
+				return &FileError{Path: f.Local, Err: fmt.Errorf("changed by a transform, so %s no longer matches it", e.Local)}
+			}
+			f.Encodings = append(f.Encodings, &_escEncoding{
+				Name:    p.encoding,
+				Data:    e.Data,
+				Encoded: base64Lines(e.Data),
+			})
+			attached[e] = true
+			if g.renamed == nil {
+				g.renamed = make(map[string]string)
+			}
+			g.renamed[e.Name] = ""
+		}
+	}
+	if len(attached) == 0 {
+		return nil
+	}
+	files := g.files[:0]
+	for _, f := range g.files {
+		if !attached[f] {
+			files = append(files, f)
+		}
+	}
+	g.files = files
+	return nil
+}
+
+// base64Lines returns b in base64, on lines of 80 characters, starting with
+// a newline.
+func base64Lines(b []byte) string {
+	var buf bytes.Buffer
+	b64 := base64.NewEncoder(base64.StdEncoding, &buf)
+	b64.Write(b)
+	b64.Close()
+	res := "\n"
+	chunk := make([]byte, 80)
+	for n, _ := buf.Read(chunk); n > 0; n, _ = buf.Read(chunk) {
+		res += string(chunk[0:n]) + "\n"
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package embed
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// stat is the size and compression statistics of an embedded file or
+// directory, as written to Config.Stats.
+type stat struct {
+	Name       string  `json:"name"`
+	Dir        bool    `json:"dir,omitempty"`
+	Size       int64   `json:"size"`
+	Compressed int64   `json:"compressed"`
+	Ratio      float64 `json:"ratio"`
+	// Time is the time spent compressing, in nanoseconds.
+	Time time.Duration `json:"time_ns"`
+	// Stored is set for files stored as they are because they already
+	// were compressed.
+	Stored bool `json:"stored,omitempty"`
+}
+
+// fileStats returns the statistics of the collected files and the directories
+// holding them, sorted by name. The numbers for a directory are the totals
+// of the files below it.
+func (g *generator) fileStats() []stat {
+	sizes := g.dirSizes()
+	stats := make([]stat, 0, len(g.files)+len(sizes))
+	for _, f := range g.files {
+		stats = append(stats, stat{
+			Name:       f.Name,
+			Size:       int64(len(f.Data)),
+			Compressed: f.compressedSize(),
+			Time:       f.compressTime,
+			Stored:     f.stored,
+		})
+	}
+	for dir, s := range sizes {
+		stats = append(stats, stat{
+			Name:       dir,
+			Dir:        true,
+			Size:       s.size,
+			Compressed: s.compressed,
+			Time:       s.compressTime,
+		})
+	}
+	for i := range stats {
+		if stats[i].Size > 0 {
+			stats[i].Ratio = float64(stats[i].Compressed) / float64(stats[i].Size)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// writeStats writes the statistics to Config.Stats, as a table or, if
+// Config.StatsJSON is set, as a JSON array.
+func (g *generator) writeStats() error {
+	stats := g.fileStats()
+	if g.conf.StatsJSON {
+		enc := json.NewEncoder(g.conf.Stats)
+		enc.SetIndent("", "\t")
+		return enc.Encode(stats)
+	}
+	w := tabwriter.NewWriter(g.conf.Stats, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tSIZE\tCOMPRESSED\tRATIO\tTIME\n")
+	for _, s := range stats {
+		name, ratio, note := s.Name, "-", ""
+		if s.Dir && name != "/" {
+			name += "/"
+		}
+		if s.Size > 0 {
+			ratio = fmt.Sprintf("%.2f", s.Ratio)
+		}
+		if s.Stored {
+			note = " (stored)"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%v%s\n", name, s.Size, s.Compressed, ratio, s.Time.Round(time.Microsecond), note)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,73 @@
+package embed
+
+import (
+	"path"
+	"regexp"
+	"sort"
+)
+
+// Transform rewrites files between reading and compression.
+type Transform struct {
+	// Pattern is the regexp for the files to transform. Like Include, it is
+	// matched against the file path.
+	Pattern string
+	// Func receives the embedded name and the contents of a file and returns
+	// its new name and contents. Returning an empty name drops the file.
+	Func func(name string, data []byte) (string, []byte, error)
+}
+
+// transform is a compiled Transform, Command or minifier. apply receives
+// the file path as well.
+type transform struct {
+	pattern *regexp.Regexp
+	apply   func(fname, name string, data []byte) (string, []byte, error)
+}
+
+// transform passes the file fname, embedded as name, through the transforms
+// matching fname in order, and returns its new name and contents. The name
+// is empty if the file was dropped.
+func (g *generator) transform(fname, name string, data []byte) (string, []byte, error) {
+	for _, t := range g.transforms {
+		if !t.pattern.MatchString(fname) {
+			continue
+		}
+		var err error
+		if name, data, err = t.apply(fname, name, data); err != nil {
+			return "", nil, err
+		}
+		if name == "" {
+			return "", nil, nil
+		}
+		name = path.Join("/", name)
+	}
+	return name, data, nil
+}
+
+// relist updates the directory listings for the files renamed or dropped by
+// transforms. A renamed file is listed in its new directory, if that is
+// embedded.
+func (g *generator) relist() {
+	if len(g.renamed) == 0 {
+		return
+	}
+	dirs := make(map[string]*_escDir, len(g.dirs))
+	for _, d := range g.dirs {
+		dirs[d.Name] = d
+		children := d.ChildFileNames[:0]
+		for _, c := range d.ChildFileNames {
+			if _, ok := g.renamed[c]; !ok {
+				children = append(children, c)
+			}
+		}
+		d.ChildFileNames = children
+	}
+	for _, name := range g.renamed {
+		if d, ok := dirs[path.Dir(name)]; ok && name != "" {
+			d.ChildFileNames = append(d.ChildFileNames, name)
+		}
+	}
+	g.renamed = nil
+	for _, d := range g.dirs {
+		sort.Strings(d.ChildFileNames)
+	}
+}
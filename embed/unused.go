@@ -0,0 +1,334 @@
+package embed
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UnusedAsset is an embedded file that is never referenced.
+type UnusedAsset struct {
+	// File is the generated file that embeds the asset.
+	File string
+	// Name is the embedded name of the asset.
+	Name string
+}
+
+// templateExts are the extensions of the template files scanned by Unused.
+var templateExts = map[string]bool{
+	".gohtml": true,
+	".htm":    true,
+	".html":   true,
+	".tmpl":   true,
+	".tpl":    true,
+}
+
+// Unused reports the files embedded by the generated Go files that are not
+// referenced by any Go or template file below root. A file is referenced by
+// a Go string literal or template text containing its name, with or without
+// the leading slash, as a whole path or path suffix, or by its constant from
+// Config.AssetConsts. Files generated by esc are not scanned.
+func Unused(generated []string, root string) ([]UnusedAsset, error) {
+	var assets []generatedAssets
+	for _, name := range generated {
+		a, err := readGeneratedAssets(name)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+
+	var texts []string
+	idents := make(map[string]bool)
+	err := filepath.Walk(root, func(fname string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return &FileError{Path: fname, Err: err}
+		}
+		base := fi.Name()
+		if fi.IsDir() {
+			if fname != root && (base == "vendor" || base == "testdata" || strings.HasPrefix(base, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(base)
+		if ext != ".go" && !templateExts[ext] {
+			return nil
+		}
+		b, err := ioutil.ReadFile(fname)
+		if err != nil {
+			return &FileError{Path: fname, Err: err}
+		}
+		if ext != ".go" {
+			texts = append(texts, string(b))
+			return nil
+		}
+		if bytes.HasPrefix(b, []byte(`// Code generated by "esc`)) {
+			return nil
+		}
+		texts = append(texts, scanGo(b, idents)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var unused []UnusedAsset
+	for _, a := range assets {
+	Names:
+		for _, name := range a.names {
+			if idents[a.consts[name]] {
+				continue
+			}
+			for _, text := range texts {
+				if references(text, name) {
+					continue Names
+				}
+			}
+			unused = append(unused, UnusedAsset{File: a.file, Name: name})
+		}
+	}
+	return unused, nil
+}
+
+// scanGo adds the identifiers in the Go source src to idents and returns
+// its string literals.
+func scanGo(src []byte, idents map[string]bool) []string {
+	var literals []string
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	s.Init(fset.AddFile("", fset.Base(), len(src)), src, nil, 0)
+	for {
+		_, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			return literals
+		case token.IDENT:
+			idents[lit] = true
+		case token.STRING:
+			if v, err := strconv.Unquote(lit); err == nil {
+				literals = append(literals, v)
+			}
+		}
+	}
+}
+
+// references reports whether text refers to the embedded name: whether it
+// contains name without its leading slash, neither preceded nor followed by
+// more of a path.
+func references(text, name string) bool {
+	rel := strings.TrimPrefix(name, "/")
+	for i := 0; ; {
+		j := strings.Index(text[i:], rel)
+		if j < 0 {
+			return false
+		}
+		j += i
+		end := j + len(rel)
+		if (j == 0 || text[j-1] == '/' || !isPathByte(text[j-1])) && (end == len(text) || !isPathByte(text[end])) {
+			return true
+		}
+		i = j + 1
+	}
+}
+
+func isPathByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '.' || c == '-' || c == '_' || c == '/'
+}
+
+// generatedAssets are the files embedded by a generated Go file.
+type generatedAssets struct {
+	file  string
+	names []string
+	// consts maps names to their constants.
+	consts map[string]string
+}
+
+// dataVarRegexp matches the map of embedded files, capturing the identifier
+// part of its bundle.
+var dataVarRegexp = regexp.MustCompile(`^_esc(\w*_)?Data$`)
+
+// readGeneratedAssets reads the names of the files embedded by the generated
+// Go file name, including its shards and its archive, if any.
+func readGeneratedAssets(name string) (generatedAssets, error) {
+	a := generatedAssets{file: name, consts: make(map[string]string)}
+	fset := token.NewFileSet()
+	files := []string{name}
+	for i := 1; ; i++ {
+		shard := shardFileName(name, i)
+		if !isShard(shard, name) {
+			break
+		}
+		files = append(files, shard)
+	}
+	var archiveErr error
+	var constPrefix string
+	hasConsts := false
+	for i, fname := range files {
+		f, err := parser.ParseFile(fset, fname, nil, 0)
+		if err != nil {
+			return a, &FileError{Path: fname, Err: err}
+		}
+		if i == 0 {
+			constPrefix, hasConsts = functionPrefix(f)
+			constPrefix += "Asset"
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				// var _escData = map[string]*_escFile{"name": {...}}
+				// const AssetName = "name"
+				for i, ident := range n.Names {
+					if i >= len(n.Values) {
+						break
+					}
+					switch v := n.Values[i].(type) {
+					case *ast.CompositeLit:
+						if dataVarRegexp.MatchString(ident.Name) {
+							for _, elt := range v.Elts {
+								kv, ok := elt.(*ast.KeyValueExpr)
+								if !ok || isDirLit(kv.Value) {
+									continue
+								}
+								if s, ok := stringLit(kv.Key); ok {
+									a.names = append(a.names, s)
+								}
+							}
+						}
+					case *ast.BasicLit:
+						if s, ok := stringLit(v); ok && hasConsts && strings.HasPrefix(ident.Name, constPrefix) {
+							a.consts[s] = ident.Name
+						} else if ok && strings.HasSuffix(ident.Name, "ArchiveDefault") {
+							names, err := readArchiveNames(name, s)
+							if err != nil {
+								archiveErr = err
+								return false
+							}
+							a.names = append(a.names, names...)
+						}
+					}
+				}
+			case *ast.AssignStmt:
+				// _escData["name"] = &_escFile{...}
+				for _, lhs := range n.Lhs {
+					ix, ok := lhs.(*ast.IndexExpr)
+					if !ok {
+						continue
+					}
+					if x, ok := ix.X.(*ast.Ident); ok && dataVarRegexp.MatchString(x.Name) {
+						if s, ok := stringLit(ix.Index); ok {
+							a.names = append(a.names, s)
+						}
+					}
+				}
+			}
+			return true
+		})
+		if archiveErr != nil {
+			return a, archiveErr
+		}
+	}
+	sort.Strings(a.names)
+	return a, nil
+}
+
+// functionPrefix returns the prefix of the functions in the generated Go
+// file f, which also starts the names of its asset constants. It reports
+// false if f holds no embedded files.
+func functionPrefix(f *ast.File) (string, bool) {
+	ident, ok := "", false
+	for _, decl := range f.Decls {
+		d, isGen := decl.(*ast.GenDecl)
+		if !isGen || d.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range d.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				if m := dataVarRegexp.FindStringSubmatch(name.Name); m != nil {
+					ident, ok = m[1], true
+				}
+			}
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	// The functions are named after the bundle, or are unexported.
+	for _, decl := range f.Decls {
+		fn, isFunc := decl.(*ast.FuncDecl)
+		if !isFunc || fn.Recv != nil {
+			continue
+		}
+		for _, prefix := range []string{strings.TrimSuffix(ident, "_"), "_esc" + ident} {
+			if fn.Name.Name == prefix+"FS" {
+				return prefix, true
+			}
+		}
+	}
+	return "", false
+}
+
+// readArchiveNames returns the names of the files in the archive the
+// generated Go file gen reads from. A relative archive path is tried against
+// the current directory, which esc ran in, and then against the directory
+// of gen. It returns a *FileError if the archive cannot be read.
+func readArchiveNames(gen, archive string) ([]string, error) {
+	archive = filepath.FromSlash(archive)
+	index, err := readArchiveIndex(archive)
+	if os.IsNotExist(err) && !filepath.IsAbs(archive) {
+		archive = filepath.Join(filepath.Dir(gen), archive)
+		index, err = readArchiveIndex(archive)
+	}
+	if err != nil {
+		return nil, &FileError{Path: archive, Err: err}
+	}
+	var names []string
+	for _, e := range index.Entries {
+		if !e.IsDir {
+			names = append(names, e.Name)
+		}
+	}
+	return names, nil
+}
+
+// isDirLit reports whether the _escFile literal e has isDir set.
+func isDirLit(e ast.Expr) bool {
+	if u, ok := e.(*ast.UnaryExpr); ok {
+		e = u.X
+	}
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if k, ok := kv.Key.(*ast.Ident); ok && k.Name == "isDir" {
+			if v, ok := kv.Value.(*ast.Ident); ok && v.Name == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
@@ -0,0 +1,4 @@
+// Package archive shows esc output with the assets in a separate archive.
+package archive
+
+//go:generate go run ../../main.go -pkg archive -prefix ../../testdata -archive static.esc -o static.go ../../testdata/README.txt ../../testdata/index.html ../../testdata/assets/txt
@@ -0,0 +1,132 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFSString(t *testing.T) {
+	for _, name := range []string{
+		"/README.txt",
+		"/index.html",
+		"/assets/txt/1.txt",
+	} {
+		for _, useLocal := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s:uselocal=%t", name, useLocal), func(t *testing.T) {
+				want, err := ioutil.ReadFile("../../testdata" + name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := FSString(useLocal, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != string(want) {
+					t.Errorf("FSString() = %q, want %q", got, want)
+				}
+			})
+		}
+	}
+	if _, err := FSString(false, "/missing"); !os.IsNotExist(err) {
+		t.Errorf("FSString() error = %v, want not exist", err)
+	}
+}
+
+func TestReaddir(t *testing.T) {
+	f, err := FS(false).Open("/assets/txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fis, err := f.Readdir(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	if want := []string{"1.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Readdir() = %v, want %v", names, want)
+	}
+}
+
+// saveArchive restores the loaded archive when t ends.
+func saveArchive(t *testing.T) {
+	if err := _escLoadArchive(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := _escArchive.r, _escArchive.err
+	data, dirs := _escData, _escDirs
+	t.Cleanup(func() {
+		_escArchive.r, _escArchive.err = r, err
+		_escData, _escDirs = data, dirs
+	})
+	_escData, _escDirs = map[string]*_escFile{}, map[string][]string{}
+}
+
+func TestOpenAppendedArchive(t *testing.T) {
+	saveArchive(t)
+	archive, err := ioutil.ReadFile("static.esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// Stand in for an executable with the archive appended to it.
+	f.Write(bytes.Repeat([]byte("x"), 1000))
+	f.Write(archive)
+	f.Close()
+
+	if err := _escOpenArchive(f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	defer _escArchive.r.(io.Closer).Close()
+	if got := _escData["/README.txt"].offset; got < 1000 {
+		t.Errorf("offset = %d, want it past the executable", got)
+	}
+	want, err := ioutil.ReadFile("../../testdata/README.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FSByte(false, "/README.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("FSByte() = %q, want %q", got, want)
+	}
+	if err := _escOpenArchive("static.go"); err == nil {
+		t.Errorf("opening a file without an archive must err")
+	}
+}
+
+func TestLocalWithoutArchive(t *testing.T) {
+	saveArchive(t)
+	_escArchive.err = os.ErrNotExist
+
+	if _, err := FSByte(false, "/README.txt"); err == nil {
+		t.Errorf("FSByte(false) without an archive must err")
+	}
+	want, err := ioutil.ReadFile("../../testdata/README.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FSByte(true, "/README.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("FSByte(true) = %q, want %q", got, want)
+	}
+	if _, err := FS(true).Open("/assets/txt"); err != nil {
+		t.Errorf("FS(true).Open() error = %v", err)
+	}
+}
@@ -0,0 +1,6 @@
+// Package bundles shows several esc outputs sharing a package.
+package bundles
+
+//go:generate go run ../../main.go -pkg bundles -prefix ../../testdata -o static.go ../../testdata/README.txt
+//go:generate go run ../../main.go -pkg bundles -bundle Text -asset-consts -prefix ../../testdata/assets/txt -o text.go ../../testdata/assets/txt
+//go:generate go run ../../main.go -pkg bundles -bundle CSS -private -prefix ../../testdata/assets/css -o css.go ../../testdata/assets/css/noscript.css
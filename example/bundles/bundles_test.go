@@ -0,0 +1,89 @@
+package bundles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundles(t *testing.T) {
+	tests := []struct {
+		name   string
+		fsByte func(bool, string) ([]byte, error)
+		file   string
+		local  string
+	}{
+		{"default", FSByte, "/README.txt", "../../testdata/README.txt"},
+		{"Text", TextFSByte, TextAsset1Txt, "../../testdata/assets/txt/1.txt"},
+		{"CSS", _escCSS_FSByte, "/noscript.css", "../../testdata/assets/css/noscript.css"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := ioutil.ReadFile(tt.local)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, useLocal := range []bool{false, true} {
+				got, err := tt.fsByte(useLocal, tt.file)
+				if err != nil {
+					t.Fatalf("useLocal=%t: %v", useLocal, err)
+				}
+				if string(got) != string(want) {
+					t.Errorf("useLocal=%t: got %q, want %q", useLocal, got, want)
+				}
+			}
+			// Each bundle only holds its own files.
+			for _, other := range tests {
+				if other.file == tt.file {
+					continue
+				}
+				if _, err := tt.fsByte(false, other.file); !os.IsNotExist(err) {
+					t.Errorf("%s found in bundle %s: %v", other.file, tt.name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBundleLocalRoot(t *testing.T) {
+	os.Setenv("ESC_TEXT_LOCAL_ROOT", os.TempDir())
+	defer os.Unsetenv("ESC_TEXT_LOCAL_ROOT")
+	if _, err := TextFSByte(true, "/1.txt"); err == nil {
+		t.Errorf("Text bundle ignored ESC_TEXT_LOCAL_ROOT")
+	}
+	if _, err := FSByte(true, "/README.txt"); err != nil {
+		t.Errorf("default bundle used ESC_TEXT_LOCAL_ROOT: %v", err)
+	}
+}
+
+func TestLocalOnlyEmbedded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Embedded local paths start with ../../testdata, so a root of
+	// dir/example/bundles resolves them to dir/testdata.
+	root := filepath.Join(dir, "example", "bundles")
+	txt := filepath.Join(dir, "testdata", "assets", "txt")
+	for _, d := range []string{root, txt} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"1.txt", "new.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(txt, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	TextSetLocalRoot(root)
+	defer TextSetLocalRoot("")
+	if got, err := TextFSString(true, "/1.txt"); err != nil || got != "1.txt" {
+		t.Errorf("TextFSString(true, /1.txt) = %q, %v, want %q", got, err, "1.txt")
+	}
+	// Without -local-fallback, files added after generation are not served.
+	if _, err := TextFSString(true, "/new.txt"); !os.IsNotExist(err) {
+		t.Errorf("TextFSString(true, /new.txt) error = %v, want not exist", err)
+	}
+}
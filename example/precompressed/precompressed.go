@@ -0,0 +1,4 @@
+// Package precompressed shows esc output serving precompressed files.
+package precompressed
+
+//go:generate go run ../../main.go -pkg precompressed -handler -precompressed -prefix web -o static.go web
@@ -0,0 +1,78 @@
+package precompressed
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		url          string
+		accept       string
+		wantEncoding string
+		wantFile     string
+		wantType     string
+		wantVary     string
+	}{
+		{"/app.js", "gzip, deflate, br", "br", "app.js.br", "text/javascript; charset=utf-8", "Accept-Encoding"},
+		{"/app.js", "gzip", "gzip", "app.js.gz", "text/javascript; charset=utf-8", "Accept-Encoding"},
+		{"/app.js", "br;q=0, *", "gzip", "app.js.gz", "text/javascript; charset=utf-8", "Accept-Encoding"},
+		{"/app.js", "identity", "", "app.js", "text/javascript; charset=utf-8", "Accept-Encoding"},
+		{"/app.js", "", "", "app.js", "text/javascript; charset=utf-8", "Accept-Encoding"},
+		{"/style.css", "br, gzip", "gzip", "style.css.gz", "text/css; charset=utf-8", "Accept-Encoding"},
+		{"/notes.txt.gz", "br, gzip", "", "notes.txt.gz", "application/gzip", ""},
+	}
+	for _, useLocal := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s:uselocal=%t", tt.url, tt.accept, useLocal), func(t *testing.T) {
+				want, err := ioutil.ReadFile("web/" + tt.wantFile)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r := httptest.NewRequest("GET", tt.url, nil)
+				if tt.accept != "" {
+					r.Header.Set("Accept-Encoding", tt.accept)
+				}
+				w := httptest.NewRecorder()
+				Handler(FS(useLocal), nil).ServeHTTP(w, r)
+				if w.Code != 200 {
+					t.Fatalf("status = %d, want 200", w.Code)
+				}
+				if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+					t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+				}
+				if got := w.Header().Get("Content-Type"); got != tt.wantType {
+					t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+				}
+				if got := w.Header().Get("Vary"); got != tt.wantVary {
+					t.Errorf("Vary = %q, want %q", got, tt.wantVary)
+				}
+				if w.Body.String() != string(want) {
+					t.Errorf("body differs from %s", tt.wantFile)
+				}
+			})
+		}
+	}
+}
+
+func TestReaddir(t *testing.T) {
+	f, err := FS(false).Open("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fis, err := f.Readdir(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	want := []string{"app.js", "notes.txt.gz", "style.css"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Readdir() = %q, want %q", names, want)
+	}
+}
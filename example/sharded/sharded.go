@@ -0,0 +1,4 @@
+// Package sharded shows esc output split across several files.
+package sharded
+
+//go:generate go run ../../main.go -pkg sharded -prefix ../../testdata -shard-size 2048 -o static.go ../../testdata/README.txt ../../testdata/index.html ../../testdata/generic.html ../../testdata/assets/txt
@@ -0,0 +1,441 @@
+// Code generated by "esc -pkg sharded -prefix ../../testdata -shard-size 2048 -o static.go ../../testdata/README.txt ../../testdata/index.html ../../testdata/generic.html ../../testdata/assets/txt"; DO NOT EDIT.
+
+package sharded
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type _escLocalFS struct{}
+
+var _escLocal _escLocalFS
+
+type _escStaticFS struct{}
+
+var _escStatic _escStaticFS
+
+type _escDirectory struct {
+	fs   http.FileSystem
+	name string
+}
+
+type _escFile struct {
+	compressed string
+	size       int64
+	modtime    int64
+	local      string
+	isDir      bool
+	fullName   string
+
+	once sync.Once
+	// ready is set once data holds the whole inflated file.
+	ready uint32
+	data  []byte
+	name  string
+
+	// mu guards the inflation of the file by lazy readers: gr inflates it
+	// into partial until all of it is read or err is set.
+	mu      sync.Mutex
+	gr      *gzip.Reader
+	partial []byte
+	err     error
+}
+
+// _escLocalRoot, if set, is the directory local paths are resolved against.
+var _escLocalRoot string
+
+// SetLocalRoot sets the directory that the local filesystem resolves
+// paths against, overriding the ESC_LOCAL_ROOT environment variable. If neither
+// is set, paths are relative to the current working directory. It should be
+// called before the local filesystem is used.
+func SetLocalRoot(root string) {
+	_escLocalRoot = root
+}
+
+// _escLocalPath resolves the slash-separated local path against the local root.
+func _escLocalPath(local string) string {
+	local = filepath.FromSlash(local)
+	root := _escLocalRoot
+	if root == "" {
+		root = os.Getenv("ESC_LOCAL_ROOT")
+	}
+	if root != "" && !filepath.IsAbs(local) {
+		local = filepath.Join(root, local)
+	}
+	return local
+}
+
+// local returns the on-disk path for name.
+func (_escLocalFS) local(name string) (string, bool) {
+	name, ok := _escClean(name)
+	if !ok {
+		return "", false
+	}
+	f, present := _escData[name]
+	if !present || f.local == "" {
+		return "", false
+	}
+	return f.local, true
+}
+
+func (fs _escLocalFS) Open(name string) (http.File, error) {
+	local, ok := fs.local(name)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(_escLocalPath(local))
+}
+
+// lookup returns the named file without inflating it.
+func (_escStaticFS) lookup(name string) (*_escFile, error) {
+	name, ok := _escClean(name)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	f, present := _escData[name]
+	if !present {
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
+
+// prepare returns the named file, inflated.
+func (fs _escStaticFS) prepare(name string) (*_escFile, error) {
+	f, err := fs.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	f.once.Do(func() {
+		if f.size != 0 {
+			var gr *gzip.Reader
+			if gr, err = f.gzipReader(); err != nil {
+				return
+			}
+			if f.data, err = ioutil.ReadAll(gr); err != nil {
+				return
+			}
+		}
+		atomic.StoreUint32(&f.ready, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// gzipReader returns a reader inflating the contents of f.
+func (f *_escFile) gzipReader() (*gzip.Reader, error) {
+	b64 := base64.NewDecoder(base64.StdEncoding, bytes.NewBufferString(f.compressed))
+	return gzip.NewReader(b64)
+}
+
+func (fs _escStaticFS) Open(name string) (http.File, error) {
+	f, err := fs.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.File()
+}
+
+// Open opens name below the directory. Names leading out of it are not found.
+func (dir _escDirectory) Open(name string) (http.File, error) {
+	name, ok := _escClean(name)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return dir.fs.Open(path.Join("/", dir.name, name))
+}
+
+// _escClean returns the slash-separated name, relative to a root, as a clean
+// absolute path. It reports false if the name leads out of the root or holds
+// characters that are not allowed in paths.
+func _escClean(name string) (string, bool) {
+	if strings.IndexByte(name, 0) >= 0 || filepath.Separator != '/' && strings.ContainsRune(name, filepath.Separator) {
+		return "", false
+	}
+	rel := path.Clean(strings.TrimLeft(name, "/"))
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return path.Join("/", rel), true
+}
+
+// _escHTTPFile is an open embedded file.
+type _escHTTPFile struct {
+	io.ReadSeeker
+	*_escFile
+	// dirPos is the number of directory entries already read.
+	dirPos int
+}
+
+// File opens f. A file that is not inflated yet is inflated as it is read,
+// so reading a range near its start does not inflate all of it.
+func (f *_escFile) File() (http.File, error) {
+	var r io.ReadSeeker
+	if f.isDir || f.size == 0 || atomic.LoadUint32(&f.ready) == 1 {
+		r = bytes.NewReader(f.data)
+	} else {
+		r = &_escLazyReader{f: f}
+	}
+	return &_escHTTPFile{
+		ReadSeeker: r,
+		_escFile:   f,
+	}, nil
+}
+
+// _escLazyReader reads a file, inflating only as much of it as is needed.
+// Readers of a file share what is inflated, and once all of it is, the data
+// is kept for later opens.
+type _escLazyReader struct {
+	f   *_escFile
+	pos int64
+}
+
+func (r *_escLazyReader) Read(p []byte) (int, error) {
+	if r.pos >= r.f.size {
+		return 0, io.EOF
+	}
+	buf, err := r.f.inflate(r.pos + int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, buf[r.pos:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *_escLazyReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.f.size
+	default:
+		return 0, fmt.Errorf(" escFile.Seek: invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf(" escFile.Seek: negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// inflate inflates the file up to offset n, and returns what is inflated.
+func (f *_escFile) inflate(n int64) ([]byte, error) {
+	if atomic.LoadUint32(&f.ready) == 1 {
+		return f.data, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if n > f.size {
+		n = f.size
+	}
+	if int64(len(f.partial)) >= n {
+		return f.partial, nil
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.gr == nil {
+		if f.gr, f.err = f.gzipReader(); f.err != nil {
+			return nil, f.err
+		}
+	}
+	for int64(len(f.partial)) < n {
+		if len(f.partial) == cap(f.partial) {
+			// Grow the buffer as the file is read, up to its size.
+			c := 2*int64(cap(f.partial)) + 512
+			if c > f.size {
+				c = f.size
+			}
+			buf := make([]byte, len(f.partial), c)
+			copy(buf, f.partial)
+			f.partial = buf
+		}
+		m, err := f.gr.Read(f.partial[len(f.partial):cap(f.partial)])
+		f.partial = f.partial[:len(f.partial)+m]
+		if err == io.EOF && int64(len(f.partial)) == f.size {
+			break
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			f.err = err
+			return nil, err
+		}
+	}
+	if int64(len(f.partial)) == f.size {
+		f.once.Do(func() {
+			f.data = f.partial
+			atomic.StoreUint32(&f.ready, 1)
+		})
+		f.gr, f.partial = nil, nil
+		return f.data, nil
+	}
+	return f.partial, nil
+}
+
+func (f *_escFile) Close() error {
+	return nil
+}
+
+// Readdir behaves like os.File.Readdir: if count is positive, it returns at
+// most count entries following those already read, and io.EOF at the end.
+func (f *_escHTTPFile) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := f.entries()
+	if err != nil {
+		return nil, err
+	}
+	fis = fis[f.dirPos:]
+	if count <= 0 {
+		f.dirPos += len(fis)
+		return fis, nil
+	}
+	if len(fis) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(fis) {
+		count = len(fis)
+	}
+	f.dirPos += count
+	return fis[:count], nil
+}
+
+// entries returns the entries of the directory f, sorted by name.
+func (f *_escFile) entries() ([]os.FileInfo, error) {
+	if !f.isDir {
+		return nil, fmt.Errorf(" escFile.Readdir: '%s' is not directory", f.name)
+	}
+
+	names, ok := _escDirs[f.fullName]
+	if !ok {
+		return nil, fmt.Errorf(" escFile.Readdir: '%s' is directory, but we have no info about content of this dir, local=%s", f.name, f.local)
+	}
+	fis := make([]os.FileInfo, 0, len(names))
+	for _, name := range names {
+		if fi, present := _escData[name]; present {
+			fis = append(fis, fi)
+		}
+	}
+	return fis, nil
+}
+
+func (f *_escFile) Stat() (os.FileInfo, error) {
+	return f, nil
+}
+
+func (f *_escFile) Name() string {
+	return f.name
+}
+
+func (f *_escFile) Size() int64 {
+	return f.size
+}
+
+func (f *_escFile) Mode() os.FileMode {
+	if f.isDir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (f *_escFile) ModTime() time.Time {
+	return time.Unix(f.modtime, 0)
+}
+
+func (f *_escFile) IsDir() bool {
+	return f.isDir
+}
+
+func (f *_escFile) Sys() interface{} {
+	return f
+}
+
+// FS returns a http.Filesystem for the embedded assets. If useLocal is true,
+// the filesystem's contents are instead used.
+func FS(useLocal bool) http.FileSystem {
+	if useLocal {
+		return _escLocal
+	}
+	return _escStatic
+}
+
+// Dir returns a http.Filesystem for the embedded assets on a given prefix dir.
+// If useLocal is true, the filesystem's contents are instead used.
+func Dir(useLocal bool, name string) http.FileSystem {
+	if useLocal {
+		return _escDirectory{fs: _escLocal, name: name}
+	}
+	return _escDirectory{fs: _escStatic, name: name}
+}
+
+// FSByte returns the named file from the embedded assets. If useLocal is
+// true, the filesystem's contents are instead used.
+func FSByte(useLocal bool, name string) ([]byte, error) {
+	if useLocal {
+		f, err := _escLocal.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(f)
+		_ = f.Close()
+		return b, err
+	}
+	f, err := _escStatic.prepare(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.data, nil
+}
+
+// FSMustByte is the same as FSByte, but panics if name is not present.
+func FSMustByte(useLocal bool, name string) []byte {
+	b, err := FSByte(useLocal, name)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// FSString is the string version of FSByte.
+func FSString(useLocal bool, name string) (string, error) {
+	b, err := FSByte(useLocal, name)
+	return string(b), err
+}
+
+// FSMustString is the string version of FSMustByte.
+func FSMustString(useLocal bool, name string) string {
+	return string(FSMustByte(useLocal, name))
+}
+
+var _escData = map[string]*_escFile{
+
+	"/assets/txt": {
+		name:     "txt",
+		local:    `../../testdata/assets/txt`,
+		isDir:    true,
+		fullName: "/assets/txt",
+	},
+}
+
+var _escDirs = map[string][]string{
+
+	"/assets/txt": {
+		"/assets/txt/1.txt",
+	},
+}
@@ -0,0 +1,35 @@
+// Code generated by "esc -pkg sharded -prefix ../../testdata -shard-size 2048 -o static.go ../../testdata/README.txt ../../testdata/index.html ../../testdata/generic.html ../../testdata/assets/txt"; DO NOT EDIT.
+
+package sharded
+
+// This file is a shard of static.go.
+
+func init() {
+	_escData["/README.txt"] = &_escFile{
+		name:    "README.txt",
+		local:   "../../testdata/README.txt",
+		size:    930,
+		modtime: 1697691710,
+		compressed: `
+H4sIAAAAAAAC/2xSy27bMBA8W4D+YW6RjVoJUOQSoEAMt0FdNOgr+YAVtZJoU6RCLu0I6McXZJwgh4IH
+k8vxcGY09xSCPrKZ0cz4+nD//RqPP8tikNFcx6m2LPiLW9qbgy2LO8+MznlM7IOzZEC2hXLjyF5pMoiB
+EW3LHjIwttsNPtZXMFqxDYzqHevlebgsi7QeBh2gA97kfABB+FnWA9MxnbxoZXjtvGYr3KLloHuLJmoj
+IO+ibUEYYs9oSB36PCkLPVLPqE5aBhAsnzCRJ2PoGXqcDI9shUQ7i93FCOEg2vbL7Cso74wBdx0rCagm
+d2LPLZq5LP7kO35e1thgFYzuB1mh5Ym8RM/ovBtBxuQcnOX1RD37gN3FkdEwW7RO2x6GJJttoiRYWchA
+gi6aThsTQOj4lFL18PwUOUjI0kY6cEhzuA7BjZydCavB6gR7986JbLJUFuIgfoaLUuOL3bsZWnCT8//M
+o0NOKqygXPTCYU7UjzZMhsKQvoanViiIVlDJusqhuQ7b7RWqKTZGK7RuJG2XZ66ymF2EIpuV5t54Fpkx
+RjXgNJDwkX2dFFQrfIJ1Am2ViS23WdfmW1nQ/tYcbK3dux6mtfXcagk3ab/IBnb50ZuyWCxedaOK512t
+3Jg5Fzvl7AvqzlnB5sQ5wKpzVujlUGv3gv0hA/uM3f+K7GdU+6f0e2ZbLF57gKrXMsQmXVxmlZdnZHht
+SoL/5jA5mxqOB+dM+M/f/BtkLQmy/DcAcMTwf6IDAAA=
+`,
+	}
+	_escData["/assets/txt/1.txt"] = &_escFile{
+		name:    "1.txt",
+		local:   "../../testdata/assets/txt/1.txt",
+		size:    9,
+		modtime: 1697691710,
+		compressed: `
+H4sIAAAAAAAC/yrOz03VLUmtKAEMAAt5KrcJAAAA
+`,
+	}
+}
@@ -0,0 +1,47 @@
+// Code generated by "esc -pkg sharded -prefix ../../testdata -shard-size 2048 -o static.go ../../testdata/README.txt ../../testdata/index.html ../../testdata/generic.html ../../testdata/assets/txt"; DO NOT EDIT.
+
+package sharded
+
+// This file is a shard of static.go.
+
+func init() {
+	_escData["/generic.html"] = &_escFile{
+		name:    "generic.html",
+		local:   "../../testdata/generic.html",
+		size:    5858,
+		modtime: 1697691710,
+		compressed: `
+H4sIAAAAAAAC/+RYWW8bORJ+lgH/h0oPsJgBJLWdbJDBbKsxgZNMAsRZY5LBYh9L7JK6HB4dsijbwP74
+BfuQWpKdybEPC4webDbr4Mc6yCoWj1788+LDv69ewusPl2/L05Pi0Wx2ejK5xBB4Q/oOlnct6Sn8cXV6
+MqnF6KexmVsS+A/8itf6oz09mbzyRLByHhrywVnUgLYC5Ywhrxg1xEAQbUUepCa4uHgOT+ZnoFmRDQQ/
+jtTm/eRPpyezWQKUaOXpyaSoCas0mBTCoqn8jSx5VnCFa4IZ3Au5yDveVsyQIKgafSBZZFFWs58zyEc0
+i4YW2YbppnFeMlDOCllZZDdcSb2oaMOKZu3HFNiyMOpZUKhpcT5Ne/TtFy41Lazb6tZsP4InvciC3GkK
+NZFkUHtaLTIMgSTkKoTcINu5CmErZ11Qnhspv1TDIDBoKbYzrQHzwYLF0lV3oDSGsMg4zBpP2mGVlacn
+7cKPZjP4l8emIQ+tEyaTSVHxBrhaZDcdYWDu2V8TViPuSecu8q1IN8wG0qTAHjvbim7nycPZAEe7tcvK
+rTOLHLca807P/sLvcDNe1WKH0uJmtF7UW/VsP4YdJTmnvA9O+aHmABxgD0mRa96THdSiEt5QtlO17oKz
+VzYO1Xv17ARJkyEroZd82X/C77QiT1Ydyxd51PdtlZWzD271h2zMBiucyQ2LJC8VoUG7tRcuKVmjIxZ5
+opV/soVj3StUtHTu4/3KX/XUb9XONgiuPZr71b8ZyN+qf81Sx+X9yn9jeR2XD2keu6bILW72Y/cS2Y6D
+d0ixdBLs8qtnvnJBRsyTSRFICbstosYFGbl7m4E92eC182P6ZG8/FQpl5fPGs4bHT6fw+Oz8Wb+tsUh9
+vs0MLJa+P6r6336Y1+d7kk35nCyhBecteoINaRbQqGKADXqOAciygag1GuV8Onwa79gCav4U0Rwtt0LF
+mkOCYoUgUAUkjAYMri0CWyFfRZOO8XWkObx1ngxwE6KBymnnjzSiIQHbIhip25B35F2YF3kztu/uPNpO
+JQ8O0WPSvdS5smCzhuBVPxvyhtXZ+fy6WWeAWhZZd1pXvBkra8oXzpICWpMA3XbbmsObYV8kYFBTiFgh
+rNBQAFSdLbpNNp5N8lWK4qh4GcMcrkhrskLhU0w+sGRROHT2ADYN+YpJ+m+DkogB15wGc7jySIGsgI/i
+YzKSgYrRTGHDgpSgBsHekxiVOA+BBZJhD9bW5MBg9BymyUMpmEliujB69IPcFFbk14yHCtiCYaAIBkPA
+FElsGUcmIQGEFGVz6AwZvUWg2+mWly000cdktyk0noST1aLesEXfo5vDu2gV6DZ4BsArz3bNWiPs4hNQ
+pkCRg3FVmtak5MjiNS5Z0AoY55cM4jkIJ0Ig2/In1Jb6wT3ulegbDoOh53ARfVIZPQRsmCx4DrE1qTGu
+cl3w9EItwO5C6dJig1pz762OZ9pvdJQlW0f0mXGYBk359kGRfddixQ0HxXY9uCWm0MMmheYQUtusnsNF
+yiTARlLECSoWhuAUu/ApAlagWZxHEOc/xcSSjgzlbFwygnVBPE7bObaKGnEBajZkkVyYw8s2tXf217wk
+71I4WV7WkApASepT8HUGS4HQngy7rCGPkgJ2QzWrqBE2vCHvsY+ZqMWzosTnAkTpJ5hCglnxoPeyjarO
+c92JOIWlRlux7Iy/W9U6O4UlL8mmU6BLvNbs/+eh+sYm6D287b7aa6DRqFpjGrxlcwRlgN1TO0sNWf3X
+PB73s7DI+2JgVzUM18mo3njlnOwX6atuJhUd3XBUNO80DousnDdgSGpX9eUGYMuTqqYHrsEVk67Cft1x
+SN6jToq2tErtZCrjDWXlOzRU5O30PifbJgrIXUOLTOhWsr6Fa8X6PiCN9i75w3v2a/CQQU5Vefr3VYg6
+wRZSP/yfYTIUAq4pKy+7wb24Ehb0NPS4g0xXbg4f3t2ERfYkK4t84P8cyKPvXe/RhcWB21OVPbZPiEvD
+ksEGdaRF9p5sBZcDlvygRt9vcyZFnoJxVGgfRuthdRyadM6kjh7VuEw+5EN9UEQ/KZ9XlacQirx+sn/v
+nT9+8nd47wzd1OQJfndYwQ8/P3v89NlhZfkOQ71hrWkKH97BWfrN0p8Hk3jyUBK2iK5qZ+kYz6iHKX88
+Ozv7CXbrYPmNa/Wx/rm12K7cr9G2Ty3VXHT1Hcu9d4rxaL2DrhYOvPTZDg61fHd/+0UrfGeX+0VrfHev
++0WrfHvHe5ynR4l5OFPk3dWzf1dduObO87qW+xpkNRD33njSO1n5t0T7B/zRR2MLLs2/oMBr+wtsd16L
+NOGXPB+9PWbl7t2w39d+Bz+6UccfLeD37UNb2MItupe3ru3rH+muQ379KZK/mxu28+uQTtrdA92fyQTl
+ndZ0+x3CX7nwMt0I5L9WiPBj4zg9Yn2VYBTWX87dPpcecLevnOlxMz0ct54t/zsAq423xeIWAAA=
+`,
+	}
+}
@@ -0,0 +1,49 @@
+// Code generated by "esc -pkg sharded -prefix ../../testdata -shard-size 2048 -o static.go ../../testdata/README.txt ../../testdata/index.html ../../testdata/generic.html ../../testdata/assets/txt"; DO NOT EDIT.
+
+package sharded
+
+// This file is a shard of static.go.
+
+func init() {
+	_escData["/index.html"] = &_escFile{
+		name:    "index.html",
+		local:   "../../testdata/index.html",
+		size:    9054,
+		modtime: 1697691710,
+		compressed: `
+H4sIAAAAAAAC/+yaW2/bxvLAn2nA32HCAkWLvyVadvx3TksRNZymCVC7Ru3i4DyOyJG4zl6Yvcg2cD78
+wfIikRIly3EM5MF5iEnuzuzszG93qRnGb97/dX7zn6vf4ePNxZ/J/l78ZjDY3wsu0Bg2J/4Ak4ey6QT+
+udrfC3Ir+IkrhpIs/Bd+w1v+We7vBR80EUyVhoK0URI5oMwgVUKQThlycIbAyYw02Jzg/PwMjoeHwFlK
+0hD81FIb1Q9/3t8bDLxBvi3Z3wvinDDzF0FsmeWU9NoYR1Vj2U+QRUhz1IbsOHR2OngXQtRqkyhoHM4Z
+3RVK2xBSJS1JOw7vWGbzcUZzltKgvDkAJpllyAcmRU7j0YGflC7vcMJpLNVCN2fyM2ji49DYB04mJ7Ih
+5Jqm4xCNIWui1JhIIJPD1JiFnFQm1aywya4aGoFGS7x4UnosalwWT1T2AClHY8YhM4NCE1eYhcn+Xjnw
+m8EA/q2xKEhD6fUgCOKMzYFl4/Cuaggb+SlmNGCyEa7FP0mr1UK4Jc58Q9g8DuJ8lNzkzAAz8URDtGhY
+hDOO8tGye5GcwVQTHcDUcf4AmkyhpO9Zx/z/4Pz6+hgMswSWRMHREmRk2ExS5tGIsXZcbm1hfokie8es
+JT1MlYhKgsOkIjmOMCkxXhNpARomS9Yw6c7BU6+JExrKSkXe8hb42/Q24IfJuSa0foLnSgglTbNO/HjD
+OCqWznG8CQqmlilpln72FCaL8X7wJLRiOHHWKgksVRKM4gqmOECt1d0gU3cSTKoV5w9hcq6kZdKVQ8cR
+Z8uhI8ebmzjK2LxLw8dyuDYOlQElEbUtS12NlUxmdD/0PlkYytVMhUmLDVyOWunpDnyJ8/aoEisIJc7D
+Prf5dbbitLZH5xQmfdY1BEPHsI6Duv6fkSTN0lr6j+oOrnBGjwgSJ0HSmlry9/oW/qYpaZLp9si0p+pj
+vZmPsN3N01AvkjCJTYFy4S+ckJ9+1RhHvi15ZArruqeY0kSpz/3KP9StX6udSWNxplH0q//UNH+t/hmz
+uZv0K/+D2Y9usklzd9FIXFk0F8hk3w7qz4nlblt3/kBonaYMrpSxLakgiFFblnJqTCt8h2ndvQXAYk3W
+/QTeKt1uDzozzNBSmJwVmnE4OjmAo8PRaT3Rtkh+1PZdciYzsPViwe5mGQSBqJYPeEM4kzXM+VFHY5Gc
+kSSUoLRETTAnzixwTJ2BOWrmDJBkAhznKFKl/SFWaMUkIGdfHIq1YaeYMs6MN0laAr9Zk2UoQOBMIjBp
+SWdO+NeBmaMh/Kk0CWCFcQIyxZVe04iCLMjSgpa6OWlFWpnOpt3ZuRaPenATOCOoYh8zMQOj0/qpiQqW
+Ho6Gt8UsBOR2HFaHP7Y1rp0NYAryL2PdEPezXp8PHPWMwuSD4xyurdI921x3vwniqKZvlVePqelyaqg0
+rM2p6fC5VBWsMNt+tAXTt5swXeP0mrIq+muhDaq4T9GlbOJML6LrAd0c0SmzGwJ6tDWgfiG8V5JSoBlZ
+oPvK3iF8anAlCwI5GYcZwhQFGcC0QryaQ6GZ8EtRLmYzhCvinKQl88X5pSVJomWmwhyYKEhnjGx9L9D6
+RoMzVl5U66WEfYXxXfnbDuAj6K2w14Hv20F0tDNEV5V/fXx6MFr4kvvt5AU5On7l6DvkaPRuZ47OvKsr
+H/Vw1ISpOoleDqO3rxh9jxjtfqbdkCic8e8j6xSV7ypLh74gRievGH2PGI12xuivjKlN70b+Xdf/rqTU
+knUvuRv9/ytG3yFGp7ufaW7mqH71WcOo9v2cjGUTx91Lvh2dvoL0zUGKo/q33FqWQinbyQMGQTwtn7UV
++kRH8yMQZ8z7Rsnuz9Q3g0GP/YWmOVPO+FdvmnvbB4NH8Cg8HU1ub7Qa/P7+YXK0a8fj1Y7tlUP3VmOY
+/JgT56z4tSd7skntu13H/9euHUeHj/eUdG/D5JLubbdvk/Bd3C5CujkjvEZCzUGZ4aoulxGPlzgtqdEC
+BNlcZVWqIISK+HJ36YdpyohnpgvSavPqOvF5PJ+69zljQWFyiYLiqHzc7clk4SzYh4LGofWOqqtJpVid
+dPZXUXcpdTz3JHtIIPMpYP/nSRZVgqVJ9eU3s0mQMSVOF9VFr13eFtTUlNsamSq32dxodWfG4XGYxFHT
+f5uRa/fbSiH1/tf2j3ETwWwIc+SOxuE1yQwuGluix3JcHsZWVneV1tXMlil8xtIXFzG14eYMGHLbzc8e
+J2dZpsmYOMqP2y1FMjo6fgvXStBdTprgb4UZ/PDu9OjkdPV4vUSTzxnndAA3l3Do/w38f52jZH0SPYuw
+tOgqV5LW7Wmf9T8dHh7+DMtxMPnKsWrWt43F5FT95mRZ9c2GlmfPGO5a+ZNzdbyVEgqsRGlruQC5fXYx
+ZacRnllS2WmMZxdWdhrl68sr6+t0bWGuPlk7usqz6lwVD5rNcttXjUmbxk5B0Zfskx9926/wT01jaZx/
+/r6sRv/ylLJyKbqpxtq+KQ2+Lmv+y/R6XH0EUL371t8L3Jro9osj/TAUTA5vjd9pl98KPCZTVYTp/hnC
+Txx44k8E0k8VIvxcKOYrpk8SdJbx3XuXX26s9C4/uPDfWST7e3EZ2eR/AwDr5/GJXiMAAA==
+`,
+	}
+}
@@ -0,0 +1,62 @@
+package sharded
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	shards, err := filepath.Glob("static_*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shards) < 2 {
+		t.Errorf("found %d shards, want at least 2", len(shards))
+	}
+}
+
+func TestFSString(t *testing.T) {
+	for _, name := range []string{
+		"/README.txt",
+		"/index.html",
+		"/generic.html",
+		"/assets/txt/1.txt",
+	} {
+		for _, useLocal := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s:uselocal=%t", name, useLocal), func(t *testing.T) {
+				want, err := ioutil.ReadFile("../../testdata" + name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := FSString(useLocal, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != string(want) {
+					t.Errorf("FSString() = %q, want %q", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestReaddir(t *testing.T) {
+	f, err := FS(false).Open("/assets/txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fis, err := f.Readdir(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	if want := []string{"1.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Readdir() = %v, want %v", names, want)
+	}
+}
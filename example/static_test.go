@@ -3,13 +3,22 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"html/template"
+	"io"
 	"io/ioutil"
+	"math/rand"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func Test_FileServer(t *testing.T) {
@@ -72,6 +81,118 @@ func testDir(useLocal bool, t *testing.T) {
 	}
 }
 
+func TestDirOpen_escStatic(t *testing.T) {
+	testDirOpen(false, t)
+}
+
+func TestDirOpen_escLocal(t *testing.T) {
+	testDirOpen(true, t)
+}
+
+func testDirOpen(useLocal bool, t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/css/main.css", "css/main.css"},
+		{"css/main.css", "css/main.css"},
+		{"//css//main.css", "css/main.css"},
+		{"/css/./../css/main.css", "css/main.css"},
+		{"/", ""},
+		{"", ""},
+		{"/css/../../README.txt", "-"},
+		{"../README.txt", "-"},
+		{"/..", "-"},
+		{"css/main.css\x00", "-"},
+	}
+	fs := Dir(useLocal, "/assets")
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s:uselocal=%t", tt.name, useLocal), func(t *testing.T) {
+			f, err := fs.Open(tt.name)
+			if tt.want == "-" {
+				if err == nil {
+					f.Close()
+					t.Fatalf("Open(%q) succeeded, want error", tt.name)
+				}
+				if !os.IsNotExist(err) {
+					t.Errorf("Open(%q) error = %v, want not exist", tt.name, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open(%q) error = %v", tt.name, err)
+			}
+			defer f.Close()
+			if got := diskPath(t, f); got != filepath.Join("../testdata/assets", tt.want) {
+				t.Errorf("Open(%q) opened %s, want %s", tt.name, got, filepath.Join("../testdata/assets", tt.want))
+			}
+		})
+	}
+}
+
+// TestDirOpenTraversal checks that no name opens a file outside the root
+// of Dir, for known attacks and for names built at random from path
+// segments.
+func TestDirOpenTraversal(t *testing.T) {
+	root, err := filepath.Abs("../testdata/assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{
+		"/css/main.css",
+		"../README.txt",
+		"/css/../../index.html",
+		"..\\index.html",
+		"//..//index.html",
+		"css/main.css\x00",
+	}
+	segments := []string{"", ".", "..", "...", "%2e", "%2e%2e", "\\", "\\..", "..\\", "\x00", "..\x00",
+		"assets", "css", "main.css", "txt", "1.txt", "index.html", "README.txt"}
+	seps := []string{"/", "/", "//", "\\"}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		var b strings.Builder
+		for n := 1 + rnd.Intn(8); n > 0; n-- {
+			b.WriteString(seps[rnd.Intn(len(seps))])
+			b.WriteString(segments[rnd.Intn(len(segments))])
+		}
+		names = append(names, strings.TrimPrefix(b.String(), seps[rnd.Intn(2)]))
+	}
+	opened := 0
+	for _, name := range names {
+		for _, useLocal := range []bool{false, true} {
+			file, err := Dir(useLocal, "/assets").Open(name)
+			if err != nil {
+				continue
+			}
+			opened++
+			p, err := filepath.Abs(diskPath(t, file))
+			file.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p != root && !strings.HasPrefix(p, root+string(filepath.Separator)) {
+				t.Errorf("Open(%q) with useLocal=%t opened %s, outside of %s", name, useLocal, p, root)
+			}
+		}
+	}
+	if opened == 0 {
+		t.Errorf("no name opened a file")
+	}
+}
+
+// diskPath returns the path on disk of the opened file f.
+func diskPath(t testing.TB, f http.File) string {
+	if f, ok := f.(*os.File); ok {
+		return filepath.Clean(f.Name())
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Clean(filepath.FromSlash(fi.Sys().(*_escFile).local))
+}
+
 func Benchmark_FileServer(b *testing.B) {
 	benchmarksURLs := []string{
 		"/",
@@ -192,6 +313,354 @@ func testFSReaddir(useLocal bool, t *testing.T) {
 	}
 }
 
+func TestReaddirCount_escStatic(t *testing.T) {
+	testReaddirCount(false, t)
+}
+
+func TestReaddirCount_escLocal(t *testing.T) {
+	testReaddirCount(true, t)
+}
+
+func testReaddirCount(useLocal bool, t *testing.T) {
+	f, err := FS(useLocal).Open("/assets/js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.Mode().IsDir() {
+		t.Errorf("Mode() = %v, want a directory", fi.Mode())
+	}
+	var names []string
+	for {
+		fis, err := f.Readdir(3)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fis) == 0 || len(fis) > 3 {
+			t.Fatalf("Readdir(3) returned %d entries", len(fis))
+		}
+		for _, fi := range fis {
+			if fi.Mode().IsDir() || fi.Mode().Perm() == 0 {
+				t.Errorf("%s: Mode() = %v, want a readable file", fi.Name(), fi.Mode())
+			}
+			names = append(names, fi.Name())
+		}
+	}
+	sort.Strings(names)
+	want := []string{"breakpoints.min.js", "browser.min.js", "jquery.min.js", "jquery.scrollex.min.js", "jquery.scrolly.min.js", "main.js", "util.js"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Readdir = %v, want %v", names, want)
+	}
+	if fis, err := f.Readdir(-1); len(fis) != 0 || err != nil {
+		t.Errorf("Readdir(-1) at the end = %v, %v; want nothing", fis, err)
+	}
+}
+
+func TestHandler_escStatic(t *testing.T) {
+	testHandler(false, t)
+}
+
+func TestHandler_escLocal(t *testing.T) {
+	testHandler(true, t)
+}
+
+func testHandler(useLocal bool, t *testing.T) {
+	index, _ := ioutil.ReadFile("../testdata/index.html")
+	mainCSS, _ := ioutil.ReadFile("../testdata/assets/css/main.css")
+	noscriptCSS, _ := ioutil.ReadFile("../testdata/assets/css/noscript.css")
+	listing := template.Must(template.New("").Parse(`{{.Path}}:{{range .Entries}} {{.Name}} {{.Size}}{{end}}`))
+
+	tests := []struct {
+		name         string
+		opts         *HandlerOptions
+		url          string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{"file", nil, "/index.html", 200, "", string(index)},
+		{"file with slash", nil, "/index.html/", 301, "../index.html", ""},
+		{"missing", nil, "/missing", 404, "", ""},
+		{"dir without slash", nil, "/assets?x=1", 301, "assets/?x=1", ""},
+		{"default listing", nil, "/assets/", 200, "", `<a href="css/">css/</a>`},
+		{"template listing", &HandlerOptions{Listing: listing}, "/assets/css/", 200, "",
+			fmt.Sprintf("/assets/css/: main.css %d noscript.css %d", len(mainCSS), len(noscriptCSS))},
+		{"no listing", &HandlerOptions{NoListing: true}, "/assets/", 404, "", ""},
+		{"no listing file", &HandlerOptions{NoListing: true}, "/assets/css/main.css", 200, "", string(mainCSS)},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s:uselocal=%t", tt.name, useLocal), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Handler(FS(useLocal), tt.opts).ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s: status = %d, want %d", tt.url, w.Code, tt.wantStatus)
+			}
+			if loc := w.Header().Get("Location"); loc != tt.wantLocation {
+				t.Errorf("%s: Location = %q, want %q", tt.url, loc, tt.wantLocation)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("%s: body = %q, want it to contain %q", tt.url, w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDefaultListing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	long := strings.Repeat("x", 60) + ".txt"
+	if err := ioutil.WriteFile(filepath.Join(dir, long), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modtime := time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, long), modtime, modtime); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		fs       http.FileSystem
+		url      string
+		wantLine string
+	}{
+		{"long name", http.Dir(dir), "/", `<a href="` + long + `">` + long + `</a> 2020-01-02 03:04          1`},
+		{"embedded directory", FS(false), "/assets/", `<a href="css/">css/</a>` + strings.Repeat(" ", 46) + `                -          -`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Handler(tt.fs, nil).ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if !strings.Contains(w.Body.String(), tt.wantLine+"\n") {
+				t.Errorf("%s: body = %q, want a line %q", tt.url, w.Body.String(), tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestIndex_escStatic(t *testing.T) {
+	testIndex(false, t)
+}
+
+func TestIndex_escLocal(t *testing.T) {
+	testIndex(true, t)
+}
+
+func testIndex(useLocal bool, t *testing.T) {
+	index, _ := ioutil.ReadFile("../testdata/index.html")
+	mainCSS, _ := ioutil.ReadFile("../testdata/assets/css/main.css")
+	fs := FS(useLocal)
+	assets := Dir(useLocal, "/assets")
+
+	tests := []struct {
+		name         string
+		fs           http.FileSystem
+		opts         *HandlerOptions
+		url          string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+		wantType     string
+	}{
+		{"default", fs, nil, "/", 200, "", string(index), "text/html; charset=utf-8"},
+		{"no listing", fs, &HandlerOptions{NoListing: true}, "/", 200, "", string(index), "text/html; charset=utf-8"},
+		{"no index", fs, &HandlerOptions{NoIndex: true}, "/", 200, "", `<a href="index.html">index.html</a>`, "text/html; charset=utf-8"},
+		{"names", fs, &HandlerOptions{Index: []string{"index.htm", "main.css"}}, "/assets/css/", 200, "", string(mainCSS), "text/css; charset=utf-8"},
+		{"names without slash", fs, &HandlerOptions{Index: []string{"main.css"}}, "/assets/css", 301, "css/", "", ""},
+		{"directory name", fs, &HandlerOptions{Index: []string{"css", "js"}}, "/assets/", 200, "", `<a href="css/">css/</a>`, "text/html; charset=utf-8"},
+		{"dir", assets, &HandlerOptions{Index: []string{"main.css"}}, "/css/", 200, "", string(mainCSS), "text/css; charset=utf-8"},
+		{"dir without slash", assets, &HandlerOptions{Index: []string{"main.css"}}, "/css?v=2", 301, "css/?v=2", "", ""},
+		{"dir root", assets, &HandlerOptions{NoListing: true}, "/", 404, "", "", "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s:uselocal=%t", tt.name, useLocal), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Handler(tt.fs, tt.opts).ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s: status = %d, want %d", tt.url, w.Code, tt.wantStatus)
+			}
+			if loc := w.Header().Get("Location"); loc != tt.wantLocation {
+				t.Errorf("%s: Location = %q, want %q", tt.url, loc, tt.wantLocation)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("%s: Content-Type = %q, want %q", tt.url, ct, tt.wantType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("%s: body = %q, want it to contain %q", tt.url, w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRange_escStatic(t *testing.T) {
+	testRange(false, t)
+}
+
+func TestRange_escLocal(t *testing.T) {
+	testRange(true, t)
+}
+
+func testRange(useLocal bool, t *testing.T) {
+	const name = "/assets/js/util.js"
+	util, _ := ioutil.ReadFile("../testdata" + name)
+	size := len(util)
+	fi, err := os.Stat("../testdata" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modtime := fi.ModTime().UTC().Format(http.TimeFormat)
+	if !useLocal {
+		modtime = time.Unix(_escData[name].modtime, 0).UTC().Format(http.TimeFormat)
+	}
+
+	tests := []struct {
+		name         string
+		rangeHeader  string
+		ifRange      string
+		wantStatus   int
+		wantRange    string
+		wantBody     string
+		wantMultiple []string
+	}{
+		{"single", "bytes=10-19", "", 206, fmt.Sprintf("bytes 10-19/%d", size), string(util[10:20]), nil},
+		{"open-ended", fmt.Sprintf("bytes=%d-", size-5), "", 206, fmt.Sprintf("bytes %d-%d/%d", size-5, size-1, size), string(util[size-5:]), nil},
+		{"suffix", "bytes=-7", "", 206, fmt.Sprintf("bytes %d-%d/%d", size-7, size-1, size), string(util[size-7:]), nil},
+		{"clamped", fmt.Sprintf("bytes=%d-%d", size-3, size+100), "", 206, fmt.Sprintf("bytes %d-%d/%d", size-3, size-1, size), string(util[size-3:]), nil},
+		{"multiple", "bytes=0-4,100-109", "", 206, "", "", []string{string(util[0:5]), string(util[100:110])}},
+		{"unsatisfiable", fmt.Sprintf("bytes=%d-", size), "", 416, fmt.Sprintf("bytes */%d", size), "", nil},
+		{"malformed", "bytes=x-y", "", 416, "", "", nil},
+		{"if-range current", "bytes=0-9", modtime, 206, fmt.Sprintf("bytes 0-9/%d", size), string(util[:10]), nil},
+		{"if-range stale", "bytes=0-9", "Mon, 02 Jan 2006 15:04:05 GMT", 200, "", string(util), nil},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s:uselocal=%t", tt.name, useLocal), func(t *testing.T) {
+			r := httptest.NewRequest("GET", name, nil)
+			r.Header.Set("Range", tt.rangeHeader)
+			if tt.ifRange != "" {
+				r.Header.Set("If-Range", tt.ifRange)
+			}
+			w := httptest.NewRecorder()
+			Handler(FS(useLocal), nil).ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("%s: status = %d, want %d", tt.rangeHeader, w.Code, tt.wantStatus)
+			}
+			if cr := w.Header().Get("Content-Range"); cr != tt.wantRange {
+				t.Errorf("%s: Content-Range = %q, want %q", tt.rangeHeader, cr, tt.wantRange)
+			}
+			if tt.wantStatus == 416 {
+				return
+			}
+			if tt.wantMultiple == nil {
+				if w.Body.String() != tt.wantBody {
+					t.Errorf("%s: body = %q, want %q", tt.rangeHeader, w.Body.String(), tt.wantBody)
+				}
+				return
+			}
+			mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+			if err != nil || mediaType != "multipart/byteranges" {
+				t.Fatalf("%s: Content-Type = %q, want multipart/byteranges", tt.rangeHeader, w.Header().Get("Content-Type"))
+			}
+			mr := multipart.NewReader(w.Body, params["boundary"])
+			var parts []string
+			for {
+				p, err := mr.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, _ := ioutil.ReadAll(p)
+				parts = append(parts, string(b))
+			}
+			if !reflect.DeepEqual(parts, tt.wantMultiple) {
+				t.Errorf("%s: parts = %q, want %q", tt.rangeHeader, parts, tt.wantMultiple)
+			}
+		})
+	}
+}
+
+func TestRangeLazy(t *testing.T) {
+	const name = "/assets/js/jquery.min.js"
+	jquery, _ := ioutil.ReadFile("../testdata" + name)
+	// Serve a fresh copy of the file, so that no earlier request has
+	// inflated it yet.
+	orig := _escData[name]
+	f := &_escFile{
+		name:       orig.name,
+		local:      orig.local,
+		size:       orig.size,
+		modtime:    orig.modtime,
+		compressed: orig.compressed,
+	}
+	_escData[name] = f
+	defer func() { _escData[name] = orig }()
+	get := func(rangeHeader string) string {
+		r := httptest.NewRequest("GET", name, nil)
+		r.Header.Set("Range", rangeHeader)
+		w := httptest.NewRecorder()
+		Handler(FS(false), nil).ServeHTTP(w, r)
+		if w.Code != 206 {
+			t.Fatalf("%s: status = %d, want 206", rangeHeader, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	if b := get("bytes=0-99"); b != string(jquery[:100]) {
+		t.Errorf("bytes=0-99: body = %q, want %q", b, jquery[:100])
+	}
+	if atomic.LoadUint32(&f.ready) != 0 {
+		t.Errorf("bytes=0-99 inflated all of %s", name)
+	}
+	if n := cap(f.partial); n > 1024 {
+		t.Errorf("bytes=0-99 allocated %d bytes of %d", n, f.size)
+	}
+	partial := f.partial
+	if b := get("bytes=50-149"); b != string(jquery[50:150]) {
+		t.Errorf("bytes=50-149: body = %q, want %q", b, jquery[50:150])
+	}
+	if len(f.partial) != len(partial) || &f.partial[0] != &partial[0] {
+		t.Errorf("bytes=50-149 inflated %s again", name)
+	}
+	if b := get("bytes=-10"); b != string(jquery[len(jquery)-10:]) {
+		t.Errorf("bytes=-10: body = %q, want %q", b, jquery[len(jquery)-10:])
+	}
+	if atomic.LoadUint32(&f.ready) != 1 || !bytes.Equal(f.data, jquery) {
+		t.Errorf("bytes=-10 did not keep the inflated %s", name)
+	}
+	if b := get("bytes=200-299"); b != string(jquery[200:300]) {
+		t.Errorf("bytes=200-299: body = %q, want %q", b, jquery[200:300])
+	}
+}
+
+// Benchmark_RangeLazy serves the start of a file that is not inflated yet.
+func Benchmark_RangeLazy(b *testing.B) {
+	const name = "/assets/js/jquery.min.js"
+	orig := _escData[name]
+	defer func() { _escData[name] = orig }()
+	h := Handler(FS(false), nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_escData[name] = &_escFile{
+			name:       orig.name,
+			size:       orig.size,
+			modtime:    orig.modtime,
+			compressed: orig.compressed,
+		}
+		r := httptest.NewRequest("GET", name, nil)
+		r.Header.Set("Range", "bytes=0-99")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+}
+
 func TestFSMustString_escStatic(t *testing.T) {
 	testFSMustString(false, t)
 }
@@ -228,3 +697,51 @@ func testFSMustString(useLocal bool, t *testing.T) {
 		})
 	}
 }
+
+func TestSetLocalRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Embedded local paths start with ../testdata, so a root of dir/example
+	// resolves them to dir/testdata.
+	root := filepath.Join(dir, "example")
+	assets := filepath.Join(dir, "testdata", "assets")
+	for _, d := range []string{root, assets} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(assets, "new.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"/assets/new.txt", "new", false},
+		{"/assets/txt/1.txt", "", true},
+		{"/index.html/new.txt", "", true},
+	}
+	for _, setRoot := range []func(){
+		func() { SetLocalRoot(root) },
+		func() { os.Setenv("ESC_LOCAL_ROOT", root) },
+	} {
+		setRoot()
+		for _, tt := range tests {
+			got, err := FSString(true, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%q. FSString() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+				continue
+			}
+			if got != tt.want {
+				t.Errorf("%q. FSString() = %q, want %q", tt.name, got, tt.want)
+			}
+		}
+		SetLocalRoot("")
+		os.Unsetenv("ESC_LOCAL_ROOT")
+	}
+}
@@ -2,28 +2,44 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/mjibson/esc/embed"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "append" {
+		appendMain(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "unused" {
+		unusedMain(os.Args[2:])
+		return
+	}
+
 	conf := &embed.Config{
 		Invocation: strings.Join(os.Args[1:], " "),
+		Log:        os.Stderr,
 	}
 
 	flag.StringVar(&conf.OutputFile, "o", "", "Output file, else stdout.")
 	flag.StringVar(&conf.Package, "pkg", "main", "Package.")
-	flag.StringVar(&conf.Prefix, "prefix", "", "Prefix to strip from filesnames.")
-	flag.StringVar(&conf.Ignore, "ignore", "", "Regexp for files we should ignore (for example \\\\.DS_Store).")
-	flag.StringVar(&conf.Include, "include", "", "Regexp for files to include. Only files that match will be included.")
-	flag.StringVar(&conf.ModTime, "modtime", "", "Unix timestamp to override as modification time for all files.")
+	flag.StringVar(&conf.Archive, "archive", "", "Write compressed files to this archive instead of the output file.")
+	flag.Int64Var(&conf.ShardSize, "shard-size", 0, "If positive, split output into files of about this many bytes of compressed data.")
 	flag.BoolVar(&conf.Private, "private", false, "If true, do not export autogenerated functions.")
-	flag.BoolVar(&conf.NoCompression, "no-compress", false, "If true, do not compress files.")
+	flag.StringVar(&conf.Bundle, "bundle", "", "Name added to all generated identifiers, so several outputs can share a package.")
+	flag.BoolVar(&conf.Handler, "handler", false, "If true, generate a Handler function serving the files with configurable directory listings.")
+	flag.BoolVar(&conf.LocalFallback, "local-fallback", false, "If true, serve files added below embedded directories after generation in local mode, even if excluded.")
+	flag.BoolVar(&conf.AssetConsts, "asset-consts", false, "If true, generate a constant holding the name of each file.")
+	fileFlags(flag.CommandLine, conf)
 	flag.Parse()
-	conf.Files = flag.Args()
+	setFiles(conf, flag.Args())
 
 	var err error
 	out := os.Stdout
@@ -38,3 +54,234 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// fileFlags registers the flags that select and store files.
+func fileFlags(fs *flag.FlagSet, conf *embed.Config) {
+	fs.StringVar(&conf.Prefix, "prefix", "", "Prefix to strip from filesnames.")
+	fs.Var((*prefixesFlag)(&conf.Prefixes), "prefix-for", "Prefix to strip from the filenames below one name: \"name=prefix\". May be repeated.")
+	fs.StringVar(&conf.Ignore, "ignore", "", "Regexp for files we should ignore (for example \\\\.DS_Store).")
+	fs.StringVar(&conf.Include, "include", "", "Regexp for files to include. Only files that match will be included.")
+	fs.StringVar(&conf.ModTime, "modtime", "", "Unix timestamp to override as modification time for all files.")
+	fs.StringVar(&conf.Minify, "minify", "", "Regexp for CSS, HTML, JSON and SVG files to minify before compression.")
+	fs.Var((*mountsFlag)(&conf.Mounts), "mount", "Embed a file or directory under another name: \"source:/name\". May be repeated.")
+	fs.Var((*commandsFlag)(&conf.Commands), "exec", "Pipe files through a command: \"pattern [-> replacement] program [arg ...]\". May be repeated.")
+	fs.StringVar(&conf.CacheDir, "cache", "", "Directory to cache the output of -exec commands in.")
+	fs.BoolVar(&conf.NoCompression, "no-compress", false, "If true, do not compress files.")
+	fs.BoolVar(&conf.ForceCompression, "force-compress", false, "If true, compress files that already are compressed, such as PNG images.")
+	fs.BoolVar(&conf.Precompressed, "precompressed", false, "If true, embed .br and .gz files next to other files as their encodings.")
+	fs.BoolVar(&conf.Reproducible, "reproducible", false, "If true, produce output that is identical on every machine.")
+	fs.Int64Var(&conf.MaxFileSize, "max-file-size", 0, "If positive, fail if a file is larger than this many bytes.")
+	fs.Int64Var(&conf.MaxTotalSize, "max-total-size", 0, "If positive, fail if the files total more than this many bytes.")
+	fs.Int64Var(&conf.MaxCompressedSize, "max-compressed-size", 0, "If positive, fail if the files total more than this many bytes compressed.")
+	fs.Var(stderrFlag{&conf.Report}, "report", "If true, print the size of each directory before and after compression.")
+	fs.Var(statsFlag{conf}, "stats", "Print the size, compression ratio and compression time of every file and directory. Give the format as -stats=table (the default) or -stats=json.")
+	fs.StringVar(&conf.DepFile, "depfile", "", "Write a Makefile dependency file listing the files and directories read to this path.")
+	fs.BoolVar(&conf.DepFileJSON, "depfile-json", false, "If true, write the -depfile as JSON.")
+}
+
+// setFiles sets the files to embed from args. An argument "name::prefix"
+// embeds name, stripping prefix instead of -prefix.
+func setFiles(conf *embed.Config, args []string) {
+	for _, arg := range args {
+		if i := strings.Index(arg, "::"); i >= 0 {
+			if conf.Prefixes == nil {
+				conf.Prefixes = make(map[string]string)
+			}
+			conf.Prefixes[arg[:i]] = arg[i+2:]
+			arg = arg[:i]
+		}
+		conf.Files = append(conf.Files, arg)
+	}
+}
+
+// stderrFlag is a boolean flag that directs a Config writer, such as
+// Report, to standard error.
+type stderrFlag struct{ w *io.Writer }
+
+func (f stderrFlag) IsBoolFlag() bool { return true }
+
+func (f stderrFlag) String() string {
+	return strconv.FormatBool(f.w != nil && *f.w != nil)
+}
+
+func (f stderrFlag) Set(v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*f.w = nil
+	if b {
+		*f.w = os.Stderr
+	}
+	return nil
+}
+
+// statsFlag is the -stats flag. It may be given alone, or with the format
+// "table" or "json" after an equals sign. Like a boolean flag, it does not
+// take the next argument as its value.
+type statsFlag struct{ conf *embed.Config }
+
+func (f statsFlag) IsBoolFlag() bool { return true }
+
+func (f statsFlag) String() string {
+	switch {
+	case f.conf == nil || f.conf.Stats == nil:
+		return ""
+	case f.conf.StatsJSON:
+		return "json"
+	}
+	return "table"
+}
+
+func (f statsFlag) Set(v string) error {
+	f.conf.Stats, f.conf.StatsJSON = os.Stderr, false
+	switch v {
+	case "true", "table":
+	case "json":
+		f.conf.StatsJSON = true
+	case "false":
+		f.conf.Stats = nil
+	default:
+		return fmt.Errorf("want \"table\" or \"json\"")
+	}
+	return nil
+}
+
+// prefixesFlag is the repeatable -prefix-for flag. Its value is a name and
+// the prefix to strip below it, separated by the first equals sign.
+type prefixesFlag map[string]string
+
+func (f *prefixesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	var s []string
+	for name, prefix := range *f {
+		s = append(s, name+"="+prefix)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ", ")
+}
+
+func (f *prefixesFlag) Set(v string) error {
+	i := strings.Index(v, "=")
+	if i <= 0 {
+		return fmt.Errorf("want \"name=prefix\"")
+	}
+	if *f == nil {
+		*f = make(map[string]string)
+	}
+	(*f)[v[:i]] = v[i+1:]
+	return nil
+}
+
+// mountsFlag is the repeatable -mount flag. Its value is a source path and
+// the name to embed it as, separated by the last colon.
+type mountsFlag []embed.Mount
+
+func (f *mountsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	var s []string
+	for _, m := range *f {
+		s = append(s, m.Source+":"+m.Target)
+	}
+	return strings.Join(s, ", ")
+}
+
+func (f *mountsFlag) Set(v string) error {
+	i := strings.LastIndex(v, ":")
+	if i <= 0 || !strings.HasPrefix(v[i+1:], "/") {
+		return fmt.Errorf("want \"source:/name\"")
+	}
+	*f = append(*f, embed.Mount{Source: v[:i], Target: v[i+1:]})
+	return nil
+}
+
+// commandsFlag is the repeatable -exec flag. Its value is a pattern, an
+// optional "->" and replacement for the file name, and the program and its
+// arguments, separated by spaces.
+type commandsFlag []embed.Command
+
+func (f *commandsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	var s []string
+	for _, c := range *f {
+		v := c.Pattern
+		if c.Rename != "" {
+			v += " -> " + c.Rename
+		}
+		s = append(s, v+" "+strings.Join(c.Args, " "))
+	}
+	return strings.Join(s, ", ")
+}
+
+func (f *commandsFlag) Set(v string) error {
+	fields := strings.Fields(v)
+	var c embed.Command
+	if len(fields) > 0 {
+		c.Pattern, fields = fields[0], fields[1:]
+	}
+	if len(fields) > 1 && fields[0] == "->" {
+		c.Rename, fields = fields[1], fields[2:]
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("want \"pattern [-> replacement] program [arg ...]\"")
+	}
+	c.Args = fields
+	*f = append(*f, c)
+	return nil
+}
+
+// appendMain implements "esc append", which appends an archive to an
+// executable.
+func appendMain(args []string) {
+	conf := &embed.Config{Log: os.Stderr}
+	fs := flag.NewFlagSet("append", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: esc append [flag] executable [name ...]\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&conf.Bundle, "bundle", "", "Bundle name the program was generated with.")
+	fileFlags(fs, conf)
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	setFiles(conf, fs.Args()[1:])
+
+	if err := embed.Append(conf, fs.Arg(0)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// unusedMain implements "esc unused", which reports embedded files that are
+// never referenced. It exits with status 1 if there are any.
+func unusedMain(args []string) {
+	fs := flag.NewFlagSet("unused", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: esc unused [flag] generated.go ...\n")
+		fs.PrintDefaults()
+	}
+	root := fs.String("root", ".", "Directory to scan for Go and template files.")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	unused, err := embed.Unused(fs.Args(), *root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, a := range unused {
+		fmt.Printf("%s: %s\n", a.File, a.Name)
+	}
+	if len(unused) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/mjibson/esc/embed"
+)
+
+func TestStatsFlag(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		stats bool
+		json  bool
+		files []string
+	}{
+		{[]string{"dir"}, false, false, []string{"dir"}},
+		{[]string{"-stats", "dir"}, true, false, []string{"dir"}},
+		{[]string{"-stats=table", "dir"}, true, false, []string{"dir"}},
+		{[]string{"-stats=json", "dir"}, true, true, []string{"dir"}},
+		{[]string{"-stats=false", "dir"}, false, false, []string{"dir"}},
+		// The format must be joined with "=".
+		{[]string{"-stats", "json", "dir"}, true, false, []string{"json", "dir"}},
+	} {
+		conf := &embed.Config{}
+		fs := flag.NewFlagSet("esc", flag.ContinueOnError)
+		fileFlags(fs, conf)
+		if err := fs.Parse(tc.args); err != nil {
+			t.Errorf("%q: %v", tc.args, err)
+			continue
+		}
+		setFiles(conf, fs.Args())
+		if got := conf.Stats != nil; got != tc.stats {
+			t.Errorf("%q: stats = %t, want %t", tc.args, got, tc.stats)
+		}
+		if conf.StatsJSON != tc.json {
+			t.Errorf("%q: json = %t, want %t", tc.args, conf.StatsJSON, tc.json)
+		}
+		if !reflect.DeepEqual(conf.Files, tc.files) {
+			t.Errorf("%q: files = %q, want %q", tc.args, conf.Files, tc.files)
+		}
+	}
+
+	fs := flag.NewFlagSet("esc", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fileFlags(fs, &embed.Config{})
+	if err := fs.Parse([]string{"-stats=yaml"}); err == nil {
+		t.Errorf("unknown -stats format must err")
+	}
+}